@@ -0,0 +1,315 @@
+// Package apikey stores the named, scoped API keys clients authenticate
+// with.
+package apikey
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeCalculate Scope = "calculate"
+	ScopePredict   Scope = "predict"
+	ScopeAdmin     Scope = "admin" // Allows everything
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+	ErrExpiredKey = errors.New("expired API key")
+	// ErrSignatureRequired is returned when a key that may only sign requests
+	// is sent as is.
+	ErrSignatureRequired = errors.New("API key may only be used to sign requests")
+	// ErrCannotSign is returned when a key configured by its hash signs a
+	// request.
+	ErrCannotSign = errors.New("API key is configured by its hash and can't sign requests")
+)
+
+// Valid reports whether s is a known scope.
+func (s Scope) Valid() bool {
+	return s == ScopeCalculate || s == ScopePredict || s == ScopeAdmin
+}
+
+// Allowed reports whether scopes grant scope.
+func Allowed(scopes []Scope, scope Scope) bool {
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// Key is a named API key. Only a hash of its secret is kept, unless the key
+// is configured with the secret itself, which requests are then signed with.
+type Key struct {
+	Name      string
+	Scopes    []Scope
+	ExpiresAt time.Time // Zero if the key never expires
+	// SignedOnly keys are refused when sent as is, so a leaked X-API-KEY
+	// header can't be used
+	SignedOnly bool
+	// WebhookSecret signs the callbacks of requests made with the key, so
+	// only its holder can verify them. Callbacks are refused without it
+	WebhookSecret []byte
+	hash          [sha256.Size]byte
+	secret        []byte // Nil if only the hash is known
+}
+
+// NewKey returns a key authenticating secret.
+func NewKey(name string, secret string, scopes []Scope, expiresAt time.Time) Key {
+	return Key{Name: name, Scopes: scopes, ExpiresAt: expiresAt, hash: sha256.Sum256([]byte(secret)), secret: []byte(secret)}
+}
+
+// Allows reports whether the key grants scope.
+func (k Key) Allows(scope Scope) bool {
+	return Allowed(k.Scopes, scope)
+}
+
+// SigningKey returns the key signed requests are signed with: the secret
+// itself. The hash kept for keys configured by it isn't secret enough to
+// sign with, since anyone who can read the keys file knows it, so those keys
+// can't sign.
+func (k Key) SigningKey() ([]byte, error) {
+	if k.secret == nil {
+		return nil, ErrCannotSign
+	}
+	return k.secret, nil
+}
+
+// keyConfig is a key as written in a keys file.
+type keyConfig struct {
+	Name          string     `json:"name"`
+	Key           string     `json:"key"`    // The secret itself
+	SHA256        string     `json:"sha256"` // Or the hex SHA-256 of the secret
+	Scopes        []Scope    `json:"scopes"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	SignedOnly    bool       `json:"signedOnly"`
+	WebhookSecret string     `json:"webhookSecret"`
+}
+
+type keysFile struct {
+	Keys []keyConfig `json:"keys"`
+}
+
+// ParseKeys reads keys from a keys file's JSON. To rotate a key, add its
+// replacement under a new name and give the old key an expiresAt late
+// enough for every client to switch.
+func ParseKeys(data []byte) ([]Key, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file keysFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid API keys: %w", err)
+	}
+
+	keys := make([]Key, 0, len(file.Keys))
+	names := make(map[string]struct{}, len(file.Keys))
+	hashes := make(map[[sha256.Size]byte]struct{}, len(file.Keys))
+	for i, config := range file.Keys {
+		key, err := config.key()
+		if err != nil {
+			return nil, fmt.Errorf("API key %d: %w", i, err)
+		}
+		if _, exists := names[key.Name]; exists {
+			return nil, fmt.Errorf("API key name %q is duplicated", key.Name)
+		}
+		if _, exists := hashes[key.hash]; exists {
+			return nil, fmt.Errorf("API key %q has the same secret as another key", key.Name)
+		}
+		names[key.Name] = struct{}{}
+		hashes[key.hash] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (c keyConfig) key() (Key, error) {
+	if c.Name == "" {
+		return Key{}, fmt.Errorf("name is required")
+	}
+	if (c.Key == "") == (c.SHA256 == "") {
+		return Key{}, fmt.Errorf("key %q must have either a key or a sha256", c.Name)
+	}
+	if c.SignedOnly && c.Key == "" {
+		return Key{}, fmt.Errorf("key %q must have a key to be signedOnly, since keys configured by their sha256 can't sign requests", c.Name)
+	}
+	if len(c.Scopes) == 0 {
+		return Key{}, fmt.Errorf("key %q must have at least one scope", c.Name)
+	}
+	for _, scope := range c.Scopes {
+		if !scope.Valid() {
+			return Key{}, fmt.Errorf("key %q has unknown scope %q, expected %s, %s or %s", c.Name, scope, ScopeCalculate, ScopePredict, ScopeAdmin)
+		}
+	}
+
+	key := NewKey(c.Name, c.Key, c.Scopes, time.Time{})
+	key.SignedOnly = c.SignedOnly
+	if c.WebhookSecret != "" {
+		key.WebhookSecret = []byte(c.WebhookSecret)
+	}
+	if c.ExpiresAt != nil {
+		key.ExpiresAt = *c.ExpiresAt
+	}
+	if c.SHA256 != "" {
+		hash, err := hex.DecodeString(c.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return Key{}, fmt.Errorf("key %q has an invalid sha256, expected %d hex characters", c.Name, 2*sha256.Size)
+		}
+		copy(key.hash[:], hash)
+		key.secret = nil
+	}
+	return key, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the key a request authenticated
+// with.
+func NewContext(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the key a request authenticated with, if it did with
+// an API key.
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+	return key, ok
+}
+
+// Store authenticates API keys from a keys file and a fixed set of keys.
+// The file is reloaded without a restart, so keys can be rotated without
+// downtime.
+type Store struct {
+	static []Key // Not from the file
+
+	mu      sync.RWMutex
+	path    string
+	modTime time.Time
+	keys    []Key
+}
+
+// NewStore returns a Store with the given keys and no keys file.
+func NewStore(keys ...Key) *Store {
+	return &Store{static: keys}
+}
+
+// LoadFile loads the keys in the file at path, replacing any loaded before.
+func (s *Store) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseKeys(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path, s.modTime, s.keys = path, info.ModTime(), keys
+	return nil
+}
+
+// Watch reloads the keys file every interval when it has changed, until ctx
+// is done. A file that fails to load is logged and the keys already loaded
+// are kept.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		path, modTime := s.path, s.modTime
+		s.mu.RUnlock()
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.ModTime().Equal(modTime) {
+			continue
+		}
+		if err := s.LoadFile(path); err != nil {
+			slog.ErrorContext(ctx, "reloading API keys failed", "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "reloaded API keys", "keys", s.Names())
+	}
+}
+
+// Authenticate returns the key whose secret is secret.
+func (s *Store) Authenticate(secret string) (Key, error) {
+	if secret == "" {
+		return Key{}, ErrUnknownKey
+	}
+	hash := sha256.Sum256([]byte(secret))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Compared in constant time and without stopping early, so timing doesn't
+	// tell which key is close
+	var found *Key
+	for _, keys := range [][]Key{s.static, s.keys} {
+		for i := range keys {
+			if subtle.ConstantTimeCompare(hash[:], keys[i].hash[:]) == 1 {
+				found = &keys[i]
+			}
+		}
+	}
+	if found == nil {
+		return Key{}, ErrUnknownKey
+	}
+	if found.SignedOnly {
+		return *found, ErrSignatureRequired
+	}
+	return checkExpiry(*found)
+}
+
+// Lookup returns the key called name, for verifying a request it signed.
+func (s *Store) Lookup(name string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, keys := range [][]Key{s.static, s.keys} {
+		for _, key := range keys {
+			if key.Name == name {
+				return checkExpiry(key)
+			}
+		}
+	}
+	return Key{}, ErrUnknownKey
+}
+
+func checkExpiry(key Key) (Key, error) {
+	if !key.ExpiresAt.IsZero() && !time.Now().Before(key.ExpiresAt) {
+		return key, ErrExpiredKey
+	}
+	return key, nil
+}
+
+// Names returns the names of every key, including expired ones.
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.static)+len(s.keys))
+	for _, keys := range [][]Key{s.static, s.keys} {
+		for _, key := range keys {
+			names = append(names, key.Name)
+		}
+	}
+	return names
+}
@@ -14,10 +14,36 @@ type Config struct {
 	//DBPort      int    `env:"DB_PORT" envDefault:"5432"`
 	//DBSSLMode   string `env:"DB_SSLMODE" envDefault:"disable"`
 	//JWTSecret   string `env:"JWT_SECRET,required"`
-	AdminSecret string `env:"ADMIN_SECRET,required"`
+	// AdminSecret is accepted as an API key with the admin scope, named
+	// admin-secret. Either it or APIKeysFile must be set
+	AdminSecret string `env:"ADMIN_SECRET"`
+	// APIKeysFile is a JSON file of named, scoped API keys, reloaded when it
+	// changes
+	APIKeysFile string `env:"API_KEYS_FILE"`
+	// RatingProfilesFile is an optional JSON file of named rating profiles
+	RatingProfilesFile string `env:"RATING_PROFILES_FILE"`
+	// JWTIssuer enables bearer JWT authentication for tokens from this issuer,
+	// verified with the keys at JWTJWKSURL or in JWTJWKSFile
+	JWTIssuer   string `env:"JWT_ISSUER"`
+	JWTAudience string `env:"JWT_AUDIENCE"`
+	JWTJWKSURL  string `env:"JWT_JWKS_URL"`
+	JWTJWKSFile string `env:"JWT_JWKS_FILE"`
+	// JWTScopesClaim is the claim granting scopes; defaults to scope
+	JWTScopesClaim string `env:"JWT_SCOPES_CLAIM"`
+	// JWTDefaultScopes are granted to every valid token, e.g. predict
+	JWTDefaultScopes []string `env:"JWT_DEFAULT_SCOPES" envSeparator:","`
+	// WebhookSecret signs the completion callbacks of requests made with
+	// AdminSecret; keys from APIKeysFile set their own webhookSecret
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+	// WebhookAllowedNetworks are CIDR ranges callbacks may be delivered to even
+	// though they are private, loopback or link-local
+	WebhookAllowedNetworks []string `env:"WEBHOOK_ALLOWED_NETWORKS" envSeparator:","`
+	// MaxActiveJobs is how many asynchronous jobs may run at once; 0 removes
+	// the limit
+	MaxActiveJobs int `env:"MAX_ACTIVE_JOBS" envDefault:"16"`
 }
 
-func LoadEnv() {
+func LoadEnv() Config {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Did not load any .env file")
@@ -28,4 +54,5 @@ func LoadEnv() {
 	if err != nil {
 		log.Fatalf("unable to parse ennvironment variables: %s", err.Error())
 	}
+	return cfg
 }
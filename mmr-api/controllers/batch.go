@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mmr/backend/apikey"
+	"mmr/backend/jobs"
+	view "mmr/backend/models"
+	"mmr/backend/webhook"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// parseBatchRequest accepts both forms of a batch request: a plain array of
+// matches or an MMRBatchRequest object. envelope reports which it was, as
+// the response takes the same form.
+func parseBatchRequest(body []byte) (req view.MMRBatchRequest, envelope bool, err error) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		err = binding.JSON.BindBody(body, &req)
+		return req, true, err
+	}
+
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return req, false, fmt.Errorf("a batch must be an array of matches or an object with matches")
+	}
+	err = binding.JSON.BindBody(body, &req.Matches)
+	return req, false, err
+}
+
+// batchMatchError is the error of the match at Index that stopped a batch.
+type batchMatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *batchMatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *batchMatchError) Unwrap() error {
+	return e.Err
+}
+
+// callbackSecret checks that a batch's callback, if any, can be delivered
+// and returns the secret it is signed with.
+func callbackSecret(ctx context.Context, callback *view.MMRCallback, webhooks *webhook.Dispatcher) ([]byte, error) {
+	if callback == nil {
+		return nil, nil
+	}
+	secret, err := webhookSecret(ctx, webhooks)
+	if err != nil {
+		return nil, err
+	}
+	return secret, webhooks.Client.ValidateURL(ctx, callback.Url)
+}
+
+// webhookSecret returns the webhook secret of the API key a request
+// authenticated with, so each key holder can only verify their own
+// callbacks.
+func webhookSecret(ctx context.Context, webhooks *webhook.Dispatcher) ([]byte, error) {
+	if webhooks == nil {
+		return nil, fmt.Errorf("callbacks are not configured on this server")
+	}
+	key, ok := apikey.FromContext(ctx)
+	if !ok || len(key.WebhookSecret) == 0 {
+		return nil, fmt.Errorf("callbacks need an API key with a webhook secret")
+	}
+	return key.WebhookSecret, nil
+}
+
+// createBatchSummary summarises a finished synchronous batch for its
+// callback.
+func createBatchSummary(total int, response view.MMRBatchResponse, err error) view.MMRCompletionSummary {
+	summary := view.MMRCompletionSummary{
+		Status:     string(jobs.StatusSucceeded),
+		Processed:  total,
+		Total:      total,
+		Skipped:    len(response.Errors),
+		FinishedAt: time.Now(),
+	}
+	if err != nil {
+		summary.Status = string(jobs.StatusFailed)
+		summary.Error = err.Error()
+		var matchErr *batchMatchError
+		if errors.As(err, &matchErr) {
+			summary.Processed = matchErr.Index
+			summary.BatchIndex = &matchErr.Index
+		}
+	}
+	return summary
+}
+
+// batchSeeds are the initial ratings of a batch by player ID.
+type batchSeeds map[int64]view.MMRCalculationPlayerRating
+
+func newBatchSeeds(ratings []view.MMRCalculationPlayerRating) (batchSeeds, error) {
+	seeds := make(batchSeeds, len(ratings))
+	for _, rating := range ratings {
+		if _, exists := seeds[rating.Id]; exists {
+			return nil, fmt.Errorf("initial rating for player ID %d is duplicated", rating.Id)
+		}
+		seeds[rating.Id] = rating
+	}
+	return seeds, nil
+}
+
+// seedMatch returns req with the initial rating of every seeded player who
+// hasn't played yet in place of the match's rating. Only the match-specific
+// weight and inactivity are kept from the match. In strict mode a match that
+// gives a seeded or already rated player a different rating is an error;
+// otherwise that rating is ignored.
+func (s batchSeeds) seedMatch(req view.MMRCalculationRequest, playerMap PlayerMMRResultMap, strict bool) (view.MMRCalculationRequest, error) {
+	seeded := req
+	for _, team := range []*view.MMRCalculationTeam{&seeded.Team1, &seeded.Team2} {
+		players := make([]view.MMRCalculationPlayerRating, len(team.Players))
+		for i, player := range team.Players {
+			players[i] = player
+			if strict {
+				if err := s.checkConflict(player, playerMap); err != nil {
+					return view.MMRCalculationRequest{}, err
+				}
+			}
+
+			seed, isSeeded := s[player.Id]
+			if _, played := playerMap[player.Id]; played || !isSeeded {
+				continue
+			}
+			seed.Weight = player.Weight
+			seed.InactivePeriods = player.InactivePeriods
+			players[i] = seed
+		}
+		team.Players = players
+	}
+	return seeded, nil
+}
+
+func (s batchSeeds) checkConflict(player view.MMRCalculationPlayerRating, playerMap PlayerMMRResultMap) error {
+	if player.Mu == nil && player.Sigma == nil {
+		return nil
+	}
+
+	if carried, played := playerMap[player.Id]; played {
+		if !sameRating(player.Mu, carried.Player.Mu) || !sameRating(player.Sigma, carried.Player.Sigma) {
+			return fmt.Errorf("player ID %d already has a rating from an earlier match in this batch", player.Id)
+		}
+		return nil
+	}
+	if seed, isSeeded := s[player.Id]; isSeeded {
+		if seed.Mu == nil || seed.Sigma == nil || !sameRating(player.Mu, *seed.Mu) || !sameRating(player.Sigma, *seed.Sigma) {
+			return fmt.Errorf("player ID %d has a different rating in initialRatings", player.Id)
+		}
+	}
+	return nil
+}
+
+// sameRating reports whether a rating given in a match, if any, matches the
+// one the player has.
+func sameRating(given *float64, current float64) bool {
+	return given == nil || *given == current
+}
+
+// standingsTracker folds batch results into every player's final state.
+type standingsTracker map[int64]*view.MMRPlayerStanding
+
+func (s standingsTracker) record(response view.MMRCalculationResponse) {
+	for i, team := range []view.MMRTeamResult{response.Team1, response.Team2} {
+		rank := response.Outcome.Ranks[i]
+		for _, player := range team.Players {
+			standing, exists := s[player.Id]
+			if !exists {
+				standing = &view.MMRPlayerStanding{Id: player.Id, PeakMMR: player.MMR}
+				s[player.Id] = standing
+			}
+
+			standing.Mu, standing.Sigma, standing.MMR = player.Mu, player.Sigma, player.MMR
+			standing.GamesPlayed++
+			standing.PeakMMR = max(standing.PeakMMR, player.MMR)
+			switch {
+			case response.Outcome.Draw:
+				standing.Draws++
+			case rank == 1:
+				standing.Wins++
+			default:
+				standing.Losses++
+			}
+		}
+	}
+}
+
+// list returns the standings with the highest MMR first, and by player ID
+// among equal MMRs.
+func (s standingsTracker) list() []view.MMRPlayerStanding {
+	standings := make([]view.MMRPlayerStanding, 0, len(s))
+	for _, standing := range s {
+		standings = append(standings, *standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].MMR != standings[j].MMR {
+			return standings[i].MMR > standings[j].MMR
+		}
+		return standings[i].Id < standings[j].Id
+	})
+	return standings
+}
@@ -1,17 +1,25 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"mmr/backend/mmr"
 	view "mmr/backend/models"
+	"mmr/backend/webhook"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/intinig/go-openskill/types"
 )
 
-type CalculationController struct{}
+type CalculationController struct {
+	// Webhooks delivers batch callbacks; callbacks are refused without it
+	Webhooks *webhook.Dispatcher
+}
 
 // SubmitMMRCalculation godoc
 //
@@ -32,12 +40,12 @@ func (m CalculationController) SubmitMMRCalculation(c *gin.Context) {
 		return
 	}
 
-	team1, team2, err := m.calculateMatch(req, nil)
+	match, err := m.calculateMatch(req, nil)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	response := m.GenerateResponse(req, team1, team2)
+	response := m.GenerateResponse(req, match)
 
 	slog.InfoContext(c.Request.Context(), "mmr calculation",
 		"request", req,
@@ -50,148 +58,576 @@ func (m CalculationController) SubmitMMRCalculation(c *gin.Context) {
 // SubmitMMRCalculationsBatch godoc
 //
 //	@Summary		Submit multiple MMR calculation requests
-//	@Description	Submit multiple MMR calculation requests
+//	@Description	Submit a view.MMRBatchRequest, or a plain array of MMR calculation requests answered with an array of results, calculated in order with each player's rating carried forward
 //	@Tags 			Calculation
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		[]view.MMRCalculationRequest	true	"MMR Calculation Requests"
-//	@Success		200		{object}	[]view.MMRCalculationResponse	"MMR calculation results"
+//	@Param			request	body		view.MMRBatchRequest	true	"MMR Batch Request"
+//	@Success		200		{object}	view.MMRBatchResponse	"MMR calculation results"
 //	@Router			/v1/mmr-calculation/batch [post]
 func (m CalculationController) SubmitMMRCalculationsBatch(c *gin.Context) {
-	var req []view.MMRCalculationRequest
-	err := c.ShouldBindJSON(&req)
+	body, err := c.GetRawData()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req, envelope, err := parseBatchRequest(body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	seeds, err := newBatchSeeds(req.InitialRatings)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := callbackSecret(c.Request.Context(), req.Callback, m.Webhooks)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	batchResponse, err := m.calculateBatch(c.Request.Context(), req, seeds, nil)
+	if req.Callback != nil {
+		m.Webhooks.Dispatch(c.Request.Context(), secret, req.Callback.Url, createBatchSummary(len(req.Matches), batchResponse, err), nil)
+	}
 	if err != nil {
+		var matchErr *batchMatchError
+		if errors.As(err, &matchErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error(), "batchIndex": matchErr.Index, "problems": validationProblems(err)})
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	responses := make([]view.MMRCalculationResponse, len(req))
+	if !envelope {
+		// Respond with the updated team data
+		results := make([]view.MMRCalculationResponse, len(batchResponse.Results))
+		for i, response := range batchResponse.Results {
+			results[i] = *response
+		}
+		c.JSON(http.StatusOK, results)
+		return
+	}
+	c.JSON(http.StatusOK, batchResponse)
+}
+
+// calculateBatch calculates a batch's matches in order, carrying each
+// player's rating forward. progress, if set, is called with the number of
+// matches processed so far before each match and once all are done. It stops
+// early once ctx is done.
+func (m CalculationController) calculateBatch(ctx context.Context, req view.MMRBatchRequest, seeds batchSeeds, progress func(processed int)) (view.MMRBatchResponse, error) {
+	responses := make([]*view.MMRCalculationResponse, len(req.Matches))
+	var batchErrors []view.MMRBatchError
 	playerMap := make(PlayerMMRResultMap)
-	for i, r := range req {
-		team1, team2, err := m.calculateMatch(r, playerMap)
+	standings := make(standingsTracker)
+	for i, r := range req.Matches {
+		if err := ctx.Err(); err != nil {
+			return view.MMRBatchResponse{}, err
+		}
+		if progress != nil {
+			progress(i)
+		}
+
+		match, err := m.calculateBatchMatch(r, seeds, playerMap, req.Strict)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error(), "batchIndex": i})
-			return
+			if !req.ContinueOnError {
+				return view.MMRBatchResponse{}, &batchMatchError{Index: i, Err: err}
+			}
+			batchErrors = append(batchErrors, view.MMRBatchError{BatchIndex: i, Error: err.Error(), Problems: validationProblems(err)})
+			slog.WarnContext(ctx, "mmr calculation skipped",
+				"batch.index", i,
+				"request", r,
+				"error", err,
+			)
+			continue
 		}
-		response := m.GenerateResponse(r, team1, team2)
-		responses[i] = response
+		response := m.GenerateResponse(r, match)
+		responses[i] = &response
 
-		slog.InfoContext(c.Request.Context(), "mmr calculation",
+		slog.InfoContext(ctx, "mmr calculation",
 			"batch.index", i,
 			"request", r,
 			"response", response,
 		)
 
-		for _, player := range team1.Players {
-			playerMap[player.Id] = player.Player
-		}
-		for _, player := range team2.Players {
-			playerMap[player.Id] = player.Player
+		playerMap.record(match)
+		standings.record(response)
+	}
+	if progress != nil {
+		progress(len(req.Matches))
+	}
+
+	batchResponse := view.MMRBatchResponse{Results: responses, Errors: batchErrors}
+	if req.IncludeStandings {
+		batchResponse.Standings = standings.list()
+	}
+	return batchResponse, nil
+}
+
+// calculateBatchMatch validates, seeds and calculates one match of a batch.
+func (m CalculationController) calculateBatchMatch(req view.MMRCalculationRequest, seeds batchSeeds, playerMap PlayerMMRResultMap, strict bool) (MatchCalculation, error) {
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return MatchCalculation{}, err
+	}
+	seeded, err := seeds.seedMatch(req, playerMap, strict)
+	if err != nil {
+		return MatchCalculation{}, err
+	}
+	return m.calculateMatch(seeded, playerMap)
+}
+
+// SubmitMMRCalculationV2 godoc
+//
+//	@Summary		Submit a multi-team MMR calculation request
+//	@Description	Submit two or more teams' details for MMR calculation. Every team has either a score (higher is better) or a rank (1 is first); equal values are ties. Results are returned in request order
+//	@Tags 			Calculation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		view.MMRCalculationRequestV2	true	"MMR Calculation Request"
+//	@Success		200		{object}	view.MMRCalculationResponseV2	"MMR calculation result"
+//	@Router			/v2/mmr-calculation [post]
+func (m CalculationController) SubmitMMRCalculationV2(c *gin.Context) {
+	var req view.MMRCalculationRequestV2
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	match, err := m.calculateTeams(req, teamsPointer(""), nil)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response := m.GenerateResponseV2(req, match)
+
+	slog.InfoContext(c.Request.Context(), "mmr calculation",
+		"request", req,
+		"response", response,
+	)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (m CalculationController) GenerateResponseV2(r view.MMRCalculationRequestV2, match MatchCalculation) view.MMRCalculationResponseV2 {
+	results := make([]view.MMRTeamResultV2, len(match.Teams))
+	for i := range match.Teams {
+		results[i] = view.MMRTeamResultV2{
+			Score:   r.Teams[i].Score,
+			Rank:    r.Teams[i].Rank,
+			Players: m.createPlayerResults(match, i),
 		}
 	}
 
-	// Respond with the updated team data
-	c.JSON(http.StatusOK, responses)
+	return view.MMRCalculationResponseV2{
+		Teams:     results,
+		Algorithm: match.Algorithm.Name(),
+		Profile:   match.Profile,
+		Outcome:   match.Outcome,
+		CarryOver: createCarryOverResult(match.CarryOver),
+	}
 }
 
-func (m CalculationController) GenerateResponse(r view.MMRCalculationRequest, team1 mmr.TeamV2, team2 mmr.TeamV2) view.MMRCalculationResponse {
+func (m CalculationController) GenerateResponse(r view.MMRCalculationRequest, match MatchCalculation) view.MMRCalculationResponse {
 	response := view.MMRCalculationResponse{
-		Team1: m.createTeamResult(*r.Team1.Score, team1),
-		Team2: m.createTeamResult(*r.Team2.Score, team2),
+		Team1:     m.createTeamResult(*r.Team1.Score, match, 0),
+		Team2:     m.createTeamResult(*r.Team2.Score, match, 1),
+		Algorithm: match.Algorithm.Name(),
+		Profile:   match.Profile,
+		Outcome:   match.Outcome,
+		CarryOver: createCarryOverResult(match.CarryOver),
 	}
 	return response
 }
 
-type PlayerMMRResultMap map[int64]types.Rating
+type PlayerMMRResultMap map[int64]mmr.PlayerV2
 
-func (m CalculationController) calculateMatch(req view.MMRCalculationRequest, playerMap PlayerMMRResultMap) (mmr.TeamV2, mmr.TeamV2, error) {
-	if err := ensurePlayers(req); err != nil {
-		return mmr.TeamV2{}, mmr.TeamV2{}, err
+// record carries every player's rating after match forward to later matches.
+func (p PlayerMMRResultMap) record(match MatchCalculation) {
+	for _, team := range match.Teams {
+		for _, player := range team.Players {
+			p[player.Id] = player
+		}
 	}
+}
 
-	team1 := mmr.TeamV2{
-		Players: m.buildTeamPlayers(req.Team1.Players, playerMap),
-		Score:   int16(*req.Team1.Score),
+// MatchCalculation is a rated match: the algorithm used, the teams before and
+// after rating in request order, each team's chance of winning beforehand
+// and how the result was interpreted. CarryOver is set when any
+// player started from a previous season's rating, and Display when MMRs are
+// mapped for players.
+type MatchCalculation struct {
+	Algorithm        mmr.RatingAlgorithm
+	Profile          string
+	Inputs           []mmr.TeamV2
+	Teams            []mmr.TeamV2
+	WinProbabilities []float64
+	Outcome          view.MMRMatchOutcome
+	CarryOver        *mmr.CarryOverPolicy
+	Display          *mmr.DisplayMapping
+	// TierLadder is set when players' Tier was assigned by a tier ladder
+	TierLadder bool
+}
+
+func (m CalculationController) calculateMatch(req view.MMRCalculationRequest, playerMap PlayerMMRResultMap) (MatchCalculation, error) {
+	return m.calculateTeams(toV2Request(req), twoTeamsPointer(""), playerMap)
+}
+
+// displayedMMR is the MMR players see: mapped by display when it is set.
+func displayedMMR(algorithm mmr.RatingAlgorithm, display *mmr.DisplayMapping, rating types.Rating) float64 {
+	value := algorithm.DisplayValue(rating)
+	if display != nil {
+		value = display.Apply(value).Value
 	}
-	team2 := mmr.TeamV2{
-		Players: m.buildTeamPlayers(req.Team2.Players, playerMap),
-		Score:   int16(*req.Team2.Score),
+	return value
+}
+
+// calculateTeams validates and rates a match between any number of teams.
+// Every problem found is returned at once, located with teamPointer as in
+// checkTeams.
+func (m CalculationController) calculateTeams(req view.MMRCalculationRequestV2, teamPointer func(int) string, playerMap PlayerMMRResultMap) (MatchCalculation, error) {
+	var problems problemList
+	problems.checkTeams(req, "", teamPointer)
+	settings := problems.resolveSettings(req, "")
+	if err := problems.err(); err != nil {
+		return MatchCalculation{}, err
 	}
+	algorithm, options, carryOver := settings.algorithm, settings.options, settings.carryOver
 
-	t1, t2 := mmr.CalculateNewMMRV2(&team1, &team2)
-	return t1, t2, nil
+	scores, outcome := interpretOutcome(req)
+	internalTeams := make([]mmr.TeamV2, len(req.Teams))
+	carriedOver := false
+	for i, team := range req.Teams {
+		internalTeams[i] = mmr.TeamV2{
+			Players: m.buildTeamPlayers(team.Players, algorithm, carryOver, playerMap),
+			Score:   scores[i],
+		}
+		for _, player := range team.Players {
+			carriedOver = carriedOver || startsFromPreviousSeason(player, playerMap)
+		}
+	}
+	if options.Margin != nil {
+		outcome.MarginMultiplier = options.Margin.MatchMultiplier(internalTeams)
+	}
+
+	rated, err := mmr.Rate(algorithm, internalTeams, options)
+	if err != nil {
+		return MatchCalculation{}, err
+	}
+	if settings.tierLadder != nil {
+		for _, team := range rated {
+			for j := range team.Players {
+				player := &team.Players[j]
+				player.Tier = settings.tierLadder.Assign(displayedMMR(algorithm, settings.display, player.Player), player.Tier)
+			}
+		}
+	}
+	match := MatchCalculation{
+		Algorithm:        algorithm,
+		Profile:          req.Profile,
+		Inputs:           internalTeams,
+		Teams:            rated,
+		WinProbabilities: mmr.PredictWinFor(algorithm, internalTeams),
+		Outcome:          outcome,
+		Display:          settings.display,
+		TierLadder:       settings.tierLadder != nil,
+	}
+	if carriedOver {
+		match.CarryOver = &carryOver
+	}
+	return match, nil
 }
 
-func (m CalculationController) buildTeamPlayers(ratings []view.MMRCalculationPlayerRating, playerMap PlayerMMRResultMap) []mmr.PlayerV2 {
+// toV2Request converts the two-team request shape to the multi-team one.
+func toV2Request(req view.MMRCalculationRequest) view.MMRCalculationRequestV2 {
+	return view.MMRCalculationRequestV2{
+		Teams: []view.MMRCalculationTeamV2{
+			{Score: req.Team1.Score, Players: req.Team1.Players},
+			{Score: req.Team2.Score, Players: req.Team2.Players},
+		},
+		MMRCalculationOptions: req.MMRCalculationOptions,
+	}
+}
+
+// teamScore orders teams for the rating algorithms, where a higher score is
+// better. Ranks count the other way, so they are negated.
+func teamScore(team view.MMRCalculationTeamV2) int16 {
+	if team.Rank != nil {
+		return int16(-*team.Rank)
+	}
+	return int16(*team.Score)
+}
+
+func (m CalculationController) buildTeamPlayers(ratings []view.MMRCalculationPlayerRating, algorithm mmr.RatingAlgorithm, carryOver mmr.CarryOverPolicy, playerMap PlayerMMRResultMap) []mmr.PlayerV2 {
 	players := make([]mmr.PlayerV2, len(ratings))
 	for i, r := range ratings {
-		players[i] = m.createPlayer(r, playerMap)
+		players[i] = m.createPlayer(r, algorithm, carryOver, playerMap)
 	}
 	return players
 }
 
-func ensurePlayers(req view.MMRCalculationRequest) error {
-	if len(req.Team1.Players) == 0 || len(req.Team2.Players) == 0 {
-		return fmt.Errorf("each team must have at least one player")
+// startsFromPreviousSeason reports whether createPlayer carries the player's
+// rating over from a previous season.
+func startsFromPreviousSeason(playerRating view.MMRCalculationPlayerRating, playerMap PlayerMMRResultMap) bool {
+	if _, exists := playerMap[playerRating.Id]; exists {
+		return false
+	}
+	return playerRating.IsPreviousSeasonRating != nil && *playerRating.IsPreviousSeasonRating &&
+		playerRating.Mu != nil && playerRating.Sigma != nil
+}
+
+// checkTeams records every problem with the teams and players of a match.
+// teamPointer locates a team by its index, as the two request shapes name
+// their teams differently.
+func (p *problemList) checkTeams(req view.MMRCalculationRequestV2, pointer string, teamPointer func(int) string) {
+	teams := req.Teams
+	if len(teams) < 2 {
+		p.add(pointer+"/teams", "a match needs at least two teams")
 	}
-	if len(req.Team1.Players) != len(req.Team2.Players) {
-		return fmt.Errorf("both teams must have the same number of players")
+
+	if req.Outcome != "" && req.Outcome != outcomeDraw {
+		p.add(pointer+"/outcome", "unknown outcome %q, expected %q or none", req.Outcome, outcomeDraw)
+	}
+	if req.DrawMargin < 0 {
+		p.add(pointer+"/drawMargin", "drawMargin must not be negative")
+	}
+
+	ranked := len(teams) > 0 && teams[0].Rank != nil
+	if ranked && req.DrawMargin > 0 {
+		p.add(pointer+"/drawMargin", "drawMargin only applies to teams with scores")
+	}
+	if ranked && req.MarginModel != nil {
+		p.add(pointer+"/marginModel", "marginModel only applies to teams with scores")
+	}
+	for i, team := range teams {
+		if len(team.Players) == 0 {
+			p.add(teamPointer(i)+"/players", "each team must have at least one player")
+		}
+		if req.RequireEqualTeamSizes && len(team.Players) != len(teams[0].Players) {
+			p.add(teamPointer(i)+"/players", "all teams must have the same number of players")
+		}
+		if (team.Score == nil) == (team.Rank == nil) {
+			p.add(teamPointer(i), "team %d must have either a score or a rank", i)
+		}
+		if (team.Rank != nil) != ranked {
+			p.add(teamPointer(i)+"/rank", "all teams must use scores or all teams must use ranks")
+		}
+		// Rating algorithms order teams by an int16
+		if team.Score != nil && (*team.Score < math.MinInt16 || *team.Score > math.MaxInt16) {
+			p.add(teamPointer(i)+"/score", "team %d has score %d, expected %d to %d", i, *team.Score, math.MinInt16, math.MaxInt16)
+		}
+		if team.Rank != nil && (*team.Rank < 1 || *team.Rank > math.MaxInt16) {
+			p.add(teamPointer(i)+"/rank", "team %d has rank %d, expected 1 to %d", i, *team.Rank, math.MaxInt16)
+		}
 	}
 
 	playerMap := make(map[int64]struct{})
-	for _, team := range []view.MMRCalculationTeam{req.Team1, req.Team2} {
-		for _, player := range team.Players {
-			if _, exists := playerMap[player.Id]; exists {
-				return fmt.Errorf("player ID %d is duplicated", player.Id)
+	for i, team := range teams {
+		for j, player := range team.Players {
+			playerPointer := fmt.Sprintf("%s/players/%d", teamPointer(i), j)
+			if player.Id == 0 {
+				p.add(playerPointer+"/id", "id is required")
+			} else if _, exists := playerMap[player.Id]; exists {
+				p.add(playerPointer+"/id", "player ID %d is duplicated", player.Id)
 			}
 			playerMap[player.Id] = struct{}{}
+			p.checkRating(player, playerPointer)
+		}
+	}
+}
+
+// checkRating records every problem with the rating given for a player.
+func (p *problemList) checkRating(player view.MMRCalculationPlayerRating, pointer string) {
+	// Written so NaN fails too
+	if player.Weight != nil && !(*player.Weight > 0 && *player.Weight <= 1) {
+		p.add(pointer+"/weight", "player ID %d has weight %v, expected a value greater than 0 and at most 1", player.Id, *player.Weight)
+	}
+	if player.Mu != nil && (math.IsNaN(*player.Mu) || math.IsInf(*player.Mu, 0)) {
+		p.add(pointer+"/mu", "player ID %d has mu %v, expected a finite value", player.Id, *player.Mu)
+	}
+	// A sigma of 0 would leave nothing to share a team's update by
+	if player.Sigma != nil && !(*player.Sigma > 0 && !math.IsInf(*player.Sigma, 0)) {
+		p.add(pointer+"/sigma", "player ID %d has sigma %v, expected a finite value greater than 0", player.Id, *player.Sigma)
+	}
+	if player.Volatility != nil && !(*player.Volatility > 0 && !math.IsInf(*player.Volatility, 0)) {
+		p.add(pointer+"/volatility", "player ID %d has volatility %v, expected a finite value greater than 0", player.Id, *player.Volatility)
+	}
+	if player.InactivePeriods != nil && *player.InactivePeriods < 0 {
+		p.add(pointer+"/inactivePeriods", "player ID %d has inactivePeriods %d, expected at least 0", player.Id, *player.InactivePeriods)
+	}
+}
+
+// matchSettings are a match's rating settings, resolved from its profile and
+// the request's overrides.
+type matchSettings struct {
+	algorithm  mmr.RatingAlgorithm
+	options    mmr.RateOptions
+	carryOver  mmr.CarryOverPolicy
+	display    *mmr.DisplayMapping
+	tierLadder *mmr.TierLadder
+}
+
+// resolveSettings resolves a match's rating settings, recording a problem for
+// every setting that is invalid.
+func (p *problemList) resolveSettings(req view.MMRCalculationRequestV2, pointer string) matchSettings {
+	profile, err := mmr.ProfileByName(req.Profile)
+	if err != nil {
+		p.add(pointer+"/profile", "%v", err)
+	}
+	var settings matchSettings
+	settings.algorithm, err = profile.RatingAlgorithm(req.Algorithm)
+	if err != nil {
+		p.add(pointer+"/algorithm", "%v", err)
+	}
+
+	if req.MarginModel != nil {
+		model, err := mmr.NewMarginModel(req.MarginModel.Curve, req.MarginModel.Scale, req.MarginModel.Cap)
+		if err != nil {
+			p.add(pointer+"/marginModel", "%v", err)
 		}
+		settings.options.Margin = &model
 	}
 
-	return nil
+	settings.carryOver = mmr.DefaultCarryOver()
+	if profile.CarryOver != nil {
+		settings.carryOver = *profile.CarryOver
+	}
+	if req.CarryOver != nil {
+		settings.carryOver, err = mmr.NewCarryOverPolicy(req.CarryOver.Policy, req.CarryOver.Fraction, req.CarryOver.SigmaInflation, req.CarryOver.LeagueMean)
+		if err != nil {
+			p.add(pointer+"/carryOver", "%v", err)
+		}
+	}
+
+	settings.display = profile.Display
+	if req.Display != nil {
+		mapping, err := mmr.NewDisplayMapping(*req.Display)
+		if err != nil {
+			p.add(pointer+"/display", "%v", err)
+		}
+		settings.display = &mapping
+	}
+
+	settings.tierLadder = profile.TierLadder
+	if req.TierLadder != nil {
+		ladder, err := mmr.NewTierLadder(*req.TierLadder)
+		if err != nil {
+			p.add(pointer+"/tierLadder", "%v", err)
+		}
+		settings.tierLadder = &ladder
+	}
+	return settings
 }
 
 // Creates a player instance from the given MMRCalculationPlayerRating
-func (m CalculationController) createPlayer(playerRating view.MMRCalculationPlayerRating, playerMap PlayerMMRResultMap) mmr.PlayerV2 {
+func (m CalculationController) createPlayer(playerRating view.MMRCalculationPlayerRating, algorithm mmr.RatingAlgorithm, carryOver mmr.CarryOverPolicy, playerMap PlayerMMRResultMap) mmr.PlayerV2 {
+	inactivePeriods := 0
+	if playerRating.InactivePeriods != nil {
+		inactivePeriods = *playerRating.InactivePeriods
+	}
+	weight := 1.0
+	if playerRating.Weight != nil {
+		weight = *playerRating.Weight
+	}
+
 	if player, exists := playerMap[playerRating.Id]; exists {
-		return mmr.PlayerV2{
-			Id:     playerRating.Id,
-			Player: player,
-		}
+		// Inactivity and weight describe this match, not the carried-over
+		// rating. The tier from the earlier match stays the previous tier.
+		player.InactivePeriods = inactivePeriods
+		player.Weight = weight
+		return player
 	}
 
 	var internalRating types.Rating
 
 	// Check if Mu and Sigma are provided; use defaults if they are nil
 	if playerRating.Mu != nil && playerRating.Sigma != nil {
-		internalRating = mmr.RatingForPlayer(playerRating)
+		internalRating = carryOver.RatingForPlayer(playerRating, algorithm.NewRating())
 	} else {
-		internalRating = mmr.NewDefaultRating()
+		internalRating = algorithm.NewRating()
 	}
 
-	return mmr.PlayerV2{
-		Id:     playerRating.Id,
-		Player: internalRating,
+	player := mmr.PlayerV2{
+		Id:              playerRating.Id,
+		Player:          internalRating,
+		Weight:          weight,
+		InactivePeriods: inactivePeriods,
+	}
+	if playerRating.Volatility != nil {
+		player.Volatility = *playerRating.Volatility
 	}
+	if playerRating.PreviousTier != nil {
+		player.Tier = *playerRating.PreviousTier
+	}
+	return player
 }
 
 // createTeamResult constructs the MMRTeamResult from score and calculated team data
-func (m CalculationController) createTeamResult(score int, team mmr.TeamV2) view.MMRTeamResult {
+func (m CalculationController) createTeamResult(score int, match MatchCalculation, teamIndex int) view.MMRTeamResult {
+	return view.MMRTeamResult{
+		Score:   &score,
+		Players: m.createPlayerResults(match, teamIndex),
+	}
+}
+
+func (m CalculationController) createPlayerResults(match MatchCalculation, teamIndex int) []view.PlayerMMRResult {
+	team := match.Teams[teamIndex]
 	playersResults := make([]view.PlayerMMRResult, len(team.Players))
 
 	for i, player := range team.Players {
+		rawMMR := match.Algorithm.DisplayValue(player.Player)
+		input := match.Inputs[teamIndex].Players[i].Player
+		inputMMR := int(match.Algorithm.DisplayValue(input))
 		// Directly use the Mu, Sigma values from the team players
 		playersResults[i] = view.PlayerMMRResult{
 			Id:    player.Id, // Using Initials as the unique identifier
 			Mu:    player.Player.Mu,
 			Sigma: player.Player.Sigma,
-			MMR:   int(mmr.RankingDisplayValue(player.Player.Mu, player.Player.Sigma)),
+			MMR:   int(rawMMR),
+			Input: view.MMRRatingSnapshot{Mu: input.Mu, Sigma: input.Sigma, MMR: inputMMR},
+			Delta: view.MMRRatingSnapshot{
+				Mu:    player.Player.Mu - input.Mu,
+				Sigma: player.Player.Sigma - input.Sigma,
+				MMR:   int(rawMMR) - inputMMR,
+			},
+			WinProbability: match.WinProbabilities[teamIndex],
+		}
+		if match.Algorithm.Name() == mmr.AlgorithmGlicko2 {
+			volatility := player.Volatility
+			playersResults[i].Volatility = &volatility
+		}
+		if match.Display != nil {
+			display := match.Display.Apply(rawMMR)
+			playersResults[i].Display = &view.MMRDisplayValue{Value: display.Value, Label: display.Label}
+		}
+		if match.TierLadder {
+			playersResults[i].Tier = player.Tier
 		}
 	}
 
-	return view.MMRTeamResult{
-		Score:   &score,
-		Players: playersResults,
+	return playersResults
+}
+
+// createCarryOverResult echoes the parameters the carry-over policy used.
+func createCarryOverResult(policy *mmr.CarryOverPolicy) *view.MMRCarryOverPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	result := &view.MMRCarryOverPolicy{Policy: policy.Name}
+	switch policy.Name {
+	case mmr.CarryOverFractionOfDelta:
+		result.Fraction = &policy.Fraction
+	case mmr.CarryOverFull:
+		result.SigmaInflation = &policy.SigmaInflation
+	case mmr.CarryOverRegressToMean:
+		result.Fraction = &policy.Fraction
+		result.LeagueMean = &policy.LeagueMean
 	}
+	return result
 }
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mmr/backend/jobs"
+	"mmr/backend/middleware"
+	view "mmr/backend/models"
+	"mmr/backend/webhook"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobController runs batch calculations too large to answer within a
+// request's write timeout.
+type JobController struct {
+	Jobs *jobs.Manager
+	// Webhooks delivers job callbacks; callbacks are refused without it
+	Webhooks *webhook.Dispatcher
+}
+
+// SubmitMMRCalculationsBatchJob godoc
+//
+//	@Summary		Start an asynchronous batch calculation
+//	@Description	Start calculating a batch in the background, taking the same body as the batch endpoint. Poll the returned job for progress and fetch its results once it has succeeded; results always take the view.MMRBatchResponse form. A job fails at its first invalid match unless the batch sets continueOnError, and only the API key or token that started it can see or cancel it. With a callback, a signed view.MMRCompletionSummary is POSTed to its url once the job has finished, and every delivery attempt is listed in the job's status. Finished jobs are kept for an hour, and running jobs are cancelled when the server shuts down. While MAX_ACTIVE_JOBS jobs are running, new ones are refused with 503
+//	@Tags 			Jobs
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		view.MMRBatchRequest	true	"MMR Batch Request"
+//	@Success		202		{object}	view.MMRJobStatus		"Started job"
+//	@Router			/v1/jobs/mmr-calculation/batch [post]
+func (j JobController) SubmitMMRCalculationsBatchJob(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req, _, err := parseBatchRequest(body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seeds, err := newBatchSeeds(req.InitialRatings)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := callbackSecret(c.Request.Context(), req.Callback, j.Webhooks)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var onFinish jobs.FinishFunc
+	if req.Callback != nil {
+		onFinish = func(ctx context.Context, job jobs.Snapshot) {
+			_ = j.Webhooks.Deliver(ctx, secret, req.Callback.Url, createJobSummary(job), func(attempt webhook.Attempt) {
+				j.Jobs.RecordDelivery(job.ID, attempt)
+			})
+		}
+	}
+	calculation := CalculationController{}
+	job, err := j.Jobs.Submit(c.Request.Context(), jobOwner(c), len(req.Matches), func(ctx context.Context, progress func(int)) (any, error) {
+		return calculation.calculateBatch(ctx, req, seeds, progress)
+	}, onFinish)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "mmr job started",
+		"job.id", job.ID,
+		"job.total", job.Total,
+	)
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, createJobStatus(job))
+}
+
+// GetJob godoc
+//
+//	@Summary		Get the progress of an asynchronous batch calculation
+//	@Tags 			Jobs
+//	@Produce		json
+//	@Param			id	path		string				true	"Job ID"
+//	@Success		200	{object}	view.MMRJobStatus	"Job status"
+//	@Router			/v1/jobs/{id} [get]
+func (j JobController) GetJob(c *gin.Context) {
+	job, exists := j.Jobs.Get(c.Param("id"), jobOwner(c))
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, createJobStatus(job))
+}
+
+// GetJobResults godoc
+//
+//	@Summary		Get the results of an asynchronous batch calculation
+//	@Description	Get the results of a job that has succeeded. A job that is still running, has failed or was cancelled is a conflict, with its status in the error
+//	@Tags 			Jobs
+//	@Produce		json
+//	@Param			id	path		string					true	"Job ID"
+//	@Success		200	{object}	view.MMRBatchResponse	"MMR calculation results"
+//	@Router			/v1/jobs/{id}/results [get]
+func (j JobController) GetJobResults(c *gin.Context) {
+	job, exists := j.Jobs.Get(c.Param("id"), jobOwner(c))
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if job.Status != jobs.StatusSucceeded {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "job is " + string(job.Status), "status": job.Status})
+		return
+	}
+	c.JSON(http.StatusOK, job.Result)
+}
+
+// CancelJob godoc
+//
+//	@Summary		Cancel an asynchronous batch calculation
+//	@Description	Ask a running job to stop. The job's status becomes cancelled once it has stopped; cancelling a finished job does nothing
+//	@Tags 			Jobs
+//	@Produce		json
+//	@Param			id	path		string				true	"Job ID"
+//	@Success		202	{object}	view.MMRJobStatus	"Job status"
+//	@Router			/v1/jobs/{id}/cancel [post]
+func (j JobController) CancelJob(c *gin.Context) {
+	job, exists := j.Jobs.Cancel(c.Param("id"), jobOwner(c))
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "mmr job cancelled",
+		"job.id", job.ID,
+		"job.processed", job.Processed,
+	)
+
+	c.JSON(http.StatusAccepted, createJobStatus(job))
+}
+
+// jobOwner identifies the API key or bearer token a request authenticated
+// with, so that its jobs are hidden from everyone else.
+func jobOwner(c *gin.Context) string {
+	if name := c.GetString(middleware.KeyNameKey); name != "" {
+		return "key:" + name
+	}
+	if subject := c.GetString(middleware.SubjectKey); subject != "" {
+		return "sub:" + subject
+	}
+	return ""
+}
+
+func createJobStatus(job jobs.Snapshot) view.MMRJobStatus {
+	status := view.MMRJobStatus{
+		Id:        job.ID,
+		Status:    string(job.Status),
+		Processed: job.Processed,
+		Total:     job.Total,
+		CreatedAt: job.CreatedAt,
+	}
+	if !job.FinishedAt.IsZero() {
+		status.FinishedAt = &job.FinishedAt
+	}
+	if job.Err != nil {
+		status.Error = job.Err.Error()
+		var matchErr *batchMatchError
+		if errors.As(job.Err, &matchErr) {
+			status.BatchIndex = &matchErr.Index
+		}
+	}
+	for _, attempt := range job.Deliveries {
+		status.Deliveries = append(status.Deliveries, view.MMRDeliveryAttempt{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+		})
+	}
+	return status
+}
+
+// createJobSummary summarises a finished job for its callback.
+func createJobSummary(job jobs.Snapshot) view.MMRCompletionSummary {
+	status := createJobStatus(job)
+	summary := view.MMRCompletionSummary{
+		JobId:      job.ID,
+		Status:     status.Status,
+		Processed:  status.Processed,
+		Total:      status.Total,
+		Error:      status.Error,
+		BatchIndex: status.BatchIndex,
+		FinishedAt: job.FinishedAt,
+	}
+	if response, ok := job.Result.(view.MMRBatchResponse); ok {
+		summary.Skipped = len(response.Errors)
+	}
+	return summary
+}
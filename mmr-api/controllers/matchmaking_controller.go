@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"log/slog"
+	"mmr/backend/mmr"
+	view "mmr/backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MatchmakingController struct{}
+
+// BalanceTeams godoc
+//
+//	@Summary		Split a pool of players into balanced teams
+//	@Description	Split a pool of exactly twice teamSize players into two teams and return the splits with the highest predicted match quality, best first. The first player in the pool is always on the first team and the same pool always gives the same splits. Players without a rating use the default rating
+//	@Tags 			Matchmaking
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		view.MatchmakingBalanceRequest	true	"Matchmaking Balance Request"
+//	@Success		200		{object}	view.MatchmakingBalanceResponse	"Balanced team splits"
+//	@Router			/v1/matchmaking/balance [post]
+func (m MatchmakingController) BalanceTeams(c *gin.Context) {
+	var req view.MatchmakingBalanceRequest
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ensureUniquePlayers(req.Players); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	top := req.Top
+	if top == 0 {
+		top = 1
+	}
+	splits, err := mmr.BalanceTeams(buildPredictionPlayers(req.Players), req.TeamSize, top)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := view.MatchmakingBalanceResponse{Splits: make([]view.MatchmakingSplit, len(splits))}
+	for i, split := range splits {
+		response.Splits[i] = m.createSplitResult(split)
+	}
+
+	slog.InfoContext(c.Request.Context(), "matchmaking balance",
+		"request", req,
+		"response", response,
+	)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (m MatchmakingController) createSplitResult(split mmr.TeamSplit) view.MatchmakingSplit {
+	winProbabilities := mmr.PredictWin(split.Teams)
+	teams := make([]view.MatchmakingTeam, len(split.Teams))
+	for i, team := range split.Teams {
+		ids := make([]int64, len(team.Players))
+		for j, player := range team.Players {
+			ids[j] = player.Id
+		}
+		teams[i] = view.MatchmakingTeam{PlayerIds: ids, WinProbability: winProbabilities[i]}
+	}
+	return view.MatchmakingSplit{Teams: teams, MatchQuality: split.MatchQuality}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"sort"
+
+	view "mmr/backend/models"
+)
+
+// Outcome modes a request can ask for. An empty mode reads the result from
+// the teams' scores or ranks.
+const (
+	outcomeDraw = "draw"
+)
+
+// Reasons reported in view.MMRMatchOutcome.
+const (
+	outcomeReasonScores     = "scores"
+	outcomeReasonRanks      = "ranks"
+	outcomeReasonDrawMargin = "draw-margin"
+	outcomeReasonExplicit   = "explicit"
+)
+
+// interpretOutcome turns the teams' scores or ranks into the ordering the
+// rating algorithms use, where a higher score is better and equal scores
+// tie, after applying the request's outcome mode and draw margin.
+func interpretOutcome(req view.MMRCalculationRequestV2) ([]int16, view.MMRMatchOutcome) {
+	scores := make([]int16, len(req.Teams))
+	for i, team := range req.Teams {
+		scores[i] = teamScore(team)
+	}
+
+	reason := outcomeReasonScores
+	if req.Teams[0].Rank != nil {
+		reason = outcomeReasonRanks
+	}
+
+	switch {
+	case req.Outcome == outcomeDraw:
+		for i := range scores {
+			scores[i] = scores[0]
+		}
+		reason = outcomeReasonExplicit
+	case req.DrawMargin > 0:
+		if applyDrawMargin(scores, req.DrawMargin) {
+			reason = outcomeReasonDrawMargin
+		}
+	}
+
+	ranks := make([]int, len(scores))
+	draw := true
+	for i, score := range scores {
+		ranks[i] = 1
+		for _, other := range scores {
+			if other > score {
+				ranks[i]++
+			}
+		}
+		draw = draw && ranks[i] == 1
+	}
+
+	return scores, view.MMRMatchOutcome{Ranks: ranks, Draw: draw, Reason: reason}
+}
+
+// applyDrawMargin ties every team whose score is within margin of the best
+// score of its group, walking from the highest score down. Comparing against
+// the group's best rather than the previous team keeps 10-9-8 with a margin
+// of 1 from collapsing into a three-way tie. It reports whether any score
+// changed.
+func applyDrawMargin(scores []int16, margin int) bool {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return scores[order[x]] > scores[order[y]]
+	})
+
+	changed := false
+	leader := scores[order[0]]
+	for _, i := range order[1:] {
+		if int(leader)-int(scores[i]) <= margin {
+			changed = changed || scores[i] != leader
+			scores[i] = leader
+		} else {
+			leader = scores[i]
+		}
+	}
+	return changed
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"fmt"
+	"log/slog"
+	"mmr/backend/mmr"
+	view "mmr/backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PredictionController struct{}
+
+// SubmitMMRPrediction godoc
+//
+//	@Summary		Predict the result of a match
+//	@Description	Predict each team's win probability, the draw probability and a match quality score from the players' ratings, without changing them. Players without a rating use the default rating
+//	@Tags 			Prediction
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		view.MMRPredictionRequest	true	"MMR Prediction Request"
+//	@Success		200		{object}	view.MMRPredictionResponse	"MMR prediction result"
+//	@Router			/v1/mmr-prediction [post]
+func (m PredictionController) SubmitMMRPrediction(c *gin.Context) {
+	var req view.MMRPredictionRequest
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ensurePredictionPlayers(req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	teams := []mmr.TeamV2{
+		{Players: buildPredictionPlayers(req.Team1.Players)},
+		{Players: buildPredictionPlayers(req.Team2.Players)},
+	}
+	winProbabilities := mmr.PredictWin(teams)
+	response := view.MMRPredictionResponse{
+		Team1:           view.MMRTeamPrediction{WinProbability: winProbabilities[0]},
+		Team2:           view.MMRTeamPrediction{WinProbability: winProbabilities[1]},
+		DrawProbability: mmr.PredictDraw(teams),
+		MatchQuality:    mmr.MatchQuality(teams),
+	}
+
+	slog.InfoContext(c.Request.Context(), "mmr prediction",
+		"request", req,
+		"response", response,
+	)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// buildPredictionPlayers rates players on the OpenSkill scale the
+// predictions use. Players without a rating get the default one.
+func buildPredictionPlayers(ratings []view.MMRCalculationPlayerRating) []mmr.PlayerV2 {
+	players := make([]mmr.PlayerV2, len(ratings))
+	for i, r := range ratings {
+		rating := mmr.NewDefaultRating()
+		if r.Mu != nil && r.Sigma != nil {
+			rating = mmr.RatingForPlayer(r)
+		}
+		players[i] = mmr.PlayerV2{Id: r.Id, Player: rating}
+	}
+	return players
+}
+
+func ensurePredictionPlayers(req view.MMRPredictionRequest) error {
+	if len(req.Team1.Players) == 0 || len(req.Team2.Players) == 0 {
+		return fmt.Errorf("each team must have at least one player")
+	}
+	return ensureUniquePlayers(req.Team1.Players, req.Team2.Players)
+}
+
+func ensureUniquePlayers(teams ...[]view.MMRCalculationPlayerRating) error {
+	playerMap := make(map[int64]struct{})
+	for _, players := range teams {
+		for _, player := range players {
+			if _, exists := playerMap[player.Id]; exists {
+				return fmt.Errorf("player ID %d is duplicated", player.Id)
+			}
+			playerMap[player.Id] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	view "mmr/backend/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON streams.
+const ndjsonContentType = "application/x-ndjson"
+
+// ReplayMMRCalculations godoc
+//
+//	@Summary		Replay a stream of MMR calculation requests
+//	@Description	Replay matches sent as newline-delimited JSON, one MMR calculation request per line, in order. Players carry their rating forward from match to match as in the batch endpoint. Each match's result is streamed back as its own line as soon as it is calculated. The stream ends at the first invalid match with a line holding the error; a replay has no size or time limit, except that a signed request's body is limited to 10 MiB
+//	@Tags 			Calculation
+//	@Accept			x-ndjson
+//	@Produce		x-ndjson
+//	@Param			request	body		view.MMRCalculationRequest	true	"MMR Calculation Requests, one per line"
+//	@Success		200		{object}	view.MMRReplayLine			"MMR calculation results, one per line"
+//	@Router			/v1/mmr-calculation/replay [post]
+func (m CalculationController) ReplayMMRCalculations(c *gin.Context) {
+	// The server's read and write timeouts are sized for single requests, not
+	// a replay of years of history. Test recorders don't support deadlines.
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+	// Results are written while the rest of the stream is still being read
+	_ = controller.EnableFullDuplex()
+
+	c.Header("Content-Type", ndjsonContentType)
+	c.Status(http.StatusOK)
+
+	decoder := json.NewDecoder(c.Request.Body)
+	encoder := json.NewEncoder(c.Writer)
+	playerMap := make(PlayerMMRResultMap)
+	replayed := 0
+	var replayErr error
+	for ; c.Request.Context().Err() == nil; replayed++ {
+		match, err := m.replayNext(decoder, playerMap)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line := view.MMRReplayLine{Index: replayed}
+		if err != nil {
+			replayErr = err
+			line.Error = err.Error()
+		} else {
+			line.Result = &match
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			replayErr = err
+			break
+		}
+		c.Writer.Flush()
+		if line.Error != "" {
+			break
+		}
+	}
+
+	if replayErr == nil {
+		replayErr = c.Request.Context().Err()
+	}
+
+	// Logging every match like the batch endpoint would flood the logs for
+	// long histories, so only the outcome of the replay is logged.
+	slog.InfoContext(c.Request.Context(), "mmr replay",
+		"replay.matches", replayed,
+		"replay.error", replayErr,
+	)
+}
+
+// replayNext decodes, validates and calculates the next match in a replay. It
+// returns io.EOF once the stream is exhausted.
+func (m CalculationController) replayNext(decoder *json.Decoder, playerMap PlayerMMRResultMap) (view.MMRCalculationResponse, error) {
+	var req view.MMRCalculationRequest
+	if err := decoder.Decode(&req); err != nil {
+		if errors.Is(err, io.EOF) {
+			return view.MMRCalculationResponse{}, err
+		}
+		return view.MMRCalculationResponse{}, fmt.Errorf("invalid match: %w", err)
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return view.MMRCalculationResponse{}, err
+	}
+
+	match, err := m.calculateMatch(req, playerMap)
+	if err != nil {
+		return view.MMRCalculationResponse{}, err
+	}
+	playerMap.record(match)
+	return m.GenerateResponse(req, match), nil
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	view "mmr/backend/models"
+	"mmr/backend/webhook"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateMMRCalculations godoc
+//
+//	@Summary		Validate MMR calculation requests without calculating them
+//	@Description	Check a single MMR calculation request, an array of them or a view.MMRBatchRequest object without rating anything. Every problem the calculation endpoints would reject is listed with a JSON pointer to the offending value, such as /matches/2/team1/players/0/sigma, rather than stopping at the first. Ratings that strict batches compare against earlier matches aren't checked, as that needs the matches to be calculated
+//	@Tags 			Calculation
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		view.MMRCalculationRequest	true	"MMR Calculation Request, array of requests or batch request"
+//	@Success		200		{object}	view.MMRValidationResponse	"Problems found"
+//	@Router			/v1/mmr-calculation/validate [post]
+func (m CalculationController) ValidateMMRCalculations(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problems, err := validateCalculationBody(c.Request.Context(), body, m.Webhooks)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "mmr validation",
+		"validation.problems", len(problems),
+	)
+
+	c.JSON(http.StatusOK, view.MMRValidationResponse{Valid: len(problems) == 0, Problems: problems})
+}
+
+// problemList collects every reason a request can't be calculated, each
+// located by a JSON pointer into the request.
+type problemList []view.MMRValidationProblem
+
+func (p *problemList) add(pointer string, format string, args ...any) {
+	*p = append(*p, view.MMRValidationProblem{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns every problem as a *validationError, or nil if there are none.
+func (p problemList) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &validationError{Problems: p}
+}
+
+// validationError is every problem that stopped a request from being
+// calculated.
+type validationError struct {
+	Problems problemList
+}
+
+func (e *validationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validationProblems returns the problems err lists, if it is a
+// *validationError.
+func validationProblems(err error) []view.MMRValidationProblem {
+	var validationErr *validationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Problems
+	}
+	return nil
+}
+
+// validateCalculationBody lists the problems in a single request, an array
+// of requests or a batch object. Only a body that isn't any of them is an
+// error. A batch's callback is checked as if the request, authenticated as
+// in ctx, had been submitted.
+func validateCalculationBody(ctx context.Context, body []byte, webhooks *webhook.Dispatcher) (problemList, error) {
+	problems := problemList{}
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var matches []view.MMRCalculationRequest
+		if err := json.Unmarshal(body, &matches); err != nil {
+			return nil, err
+		}
+		for i, match := range matches {
+			problems.checkMatch(match, fmt.Sprintf("/%d", i))
+		}
+		return problems, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("expected a calculation request, an array of them or a batch object: %w", err)
+	}
+	if _, batch := fields["matches"]; batch {
+		var req view.MMRBatchRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		problems.checkBatch(ctx, req, webhooks)
+		return problems, nil
+	}
+
+	var req view.MMRCalculationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	problems.checkMatch(req, "")
+	return problems, nil
+}
+
+// checkBatch records every problem with a batch object and its matches.
+func (p *problemList) checkBatch(ctx context.Context, req view.MMRBatchRequest, webhooks *webhook.Dispatcher) {
+	if req.Matches == nil {
+		p.add("/matches", "matches is required")
+	}
+	if req.Callback != nil {
+		if _, err := webhookSecret(ctx, webhooks); err != nil {
+			p.add("/callback", "%v", err)
+		} else if err := webhooks.Client.ValidateURL(ctx, req.Callback.Url); err != nil {
+			p.add("/callback/url", "%v", err)
+		}
+	}
+
+	seeded := make(map[int64]struct{}, len(req.InitialRatings))
+	for i, rating := range req.InitialRatings {
+		pointer := fmt.Sprintf("/initialRatings/%d", i)
+		if rating.Id == 0 {
+			p.add(pointer+"/id", "id is required")
+		} else if _, exists := seeded[rating.Id]; exists {
+			p.add(pointer+"/id", "initial rating for player ID %d is duplicated", rating.Id)
+		}
+		seeded[rating.Id] = struct{}{}
+		p.checkRating(rating, pointer)
+	}
+
+	for i, match := range req.Matches {
+		p.checkMatch(match, fmt.Sprintf("/matches/%d", i))
+	}
+}
+
+// checkMatch records every problem with a two-team request at pointer.
+func (p *problemList) checkMatch(req view.MMRCalculationRequest, pointer string) {
+	v2 := toV2Request(req)
+	p.checkTeams(v2, pointer, twoTeamsPointer(pointer))
+	p.resolveSettings(v2, pointer)
+}
+
+// twoTeamsPointer locates team1 and team2 of a two-team request at pointer.
+func twoTeamsPointer(pointer string) func(int) string {
+	return func(i int) string {
+		return fmt.Sprintf("%s/team%d", pointer, i+1)
+	}
+}
+
+// teamsPointer locates the teams of a multi-team request at pointer.
+func teamsPointer(pointer string) func(int) string {
+	return func(i int) string {
+		return fmt.Sprintf("%s/teams/%d", pointer, i)
+	}
+}
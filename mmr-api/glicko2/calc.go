@@ -0,0 +1,141 @@
+package glicko2
+
+import (
+	"math"
+)
+
+const (
+	DefaultRating     = 1500
+	DefaultRD         = 350
+	DefaultVolatility = 0.06
+	// DefaultTau constrains how much volatility can change in one rating
+	// period. Glickman recommends 0.3 to 1.2; lower values suit games with
+	// few upsets.
+	DefaultTau = 0.5
+
+	// scale converts between the Glicko scale and the Glicko-2 internal scale.
+	scale       = 173.7178
+	convergence = 0.000001
+)
+
+// Update returns the player's rating after a rating period with the given
+// results, constraining volatility changes with DefaultTau.
+func (p Player) Update(results []Result) Player {
+	return p.UpdateWithTau(results, DefaultTau)
+}
+
+// UpdateWithTau returns the player's rating after a rating period with the
+// given results. tau constrains how much volatility can change and must be
+// greater than 0. A period without results only grows the rating deviation.
+func (p Player) UpdateWithTau(results []Result, tau float64) Player {
+	if len(results) == 0 {
+		return p.Idle(1)
+	}
+
+	mu, phi := toInternal(p)
+
+	var vInv, improvement float64
+	for _, r := range results {
+		muJ, phiJ := toInternal(r.Opponent)
+		g := gFactor(phiJ)
+		e := expected(mu, muJ, g)
+		vInv += g * g * e * (1 - e)
+		improvement += g * (r.Score - e)
+	}
+	v := 1 / vInv
+	delta := v * improvement
+
+	volatility := newVolatility(phi, p.Volatility, v, delta, tau)
+	phiStar := math.Sqrt(phi*phi + volatility*volatility)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return Player{
+		Rating:     newMu*scale + DefaultRating,
+		RD:         math.Min(newPhi*scale, DefaultRD),
+		Volatility: volatility,
+	}
+}
+
+// Idle returns the player after the given number of rating periods without
+// games. Each period grows the rating deviation by the player's volatility,
+// up to the deviation of a new player. Negative periods count as none.
+func (p Player) Idle(periods int) Player {
+	if periods <= 0 {
+		return p
+	}
+	_, phi := toInternal(p)
+	// Step 6 of Glickman's example applied once per period, in closed form
+	phi = math.Sqrt(phi*phi + float64(periods)*p.Volatility*p.Volatility)
+	p.RD = math.Min(phi*scale, DefaultRD)
+	return p
+}
+
+// ExpectedScore is the probability that p beats opponent.
+func (p Player) ExpectedScore(opponent Player) float64 {
+	mu, _ := toInternal(p)
+	muJ, phiJ := toInternal(opponent)
+	return expected(mu, muJ, gFactor(phiJ))
+}
+
+// Composite combines a team into a single opponent: the mean rating and the
+// root mean square of the deviations.
+func Composite(players []Player) Player {
+	var rating, rdSq, volatility float64
+	for _, p := range players {
+		rating += p.Rating
+		rdSq += p.RD * p.RD
+		volatility += p.Volatility
+	}
+	n := float64(len(players))
+	return Player{Rating: rating / n, RD: math.Sqrt(rdSq / n), Volatility: volatility / n}
+}
+
+func toInternal(p Player) (mu, phi float64) {
+	return (p.Rating - DefaultRating) / scale, p.RD / scale
+}
+
+func gFactor(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, muJ, g float64) float64 {
+	return 1 / (1 + math.Exp(-g*(mu-muJ)))
+}
+
+// newVolatility solves for the new volatility with the Illinois algorithm
+// (step 5 of Glickman's "Example of the Glicko-2 system").
+func newVolatility(phi, volatility, v, delta, tau float64) float64 {
+	a := math.Log(volatility * volatility)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > convergence {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,21 @@
+package glicko2
+
+// Player is a Glicko-2 rating on the familiar Glicko scale: a rating around
+// 1500, its rating deviation (RD) and the volatility of the player's form.
+type Player struct {
+	Rating     float64
+	RD         float64
+	Volatility float64
+}
+
+// NewPlayer returns the rating of a player who hasn't played yet.
+func NewPlayer() Player {
+	return Player{Rating: DefaultRating, RD: DefaultRD, Volatility: DefaultVolatility}
+}
+
+// Result is the outcome of one game against an opponent: 1 for a win, 0.5
+// for a draw and 0 for a loss.
+type Result struct {
+	Opponent Player
+	Score    float64
+}
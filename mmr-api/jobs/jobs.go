@@ -0,0 +1,214 @@
+// Package jobs runs long calculations in the background so clients can poll
+// for them instead of holding a request open.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"mmr/backend/webhook"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+const (
+	// DefaultRetention is how long a finished job's result is kept.
+	DefaultRetention = time.Hour
+	// DefaultMaxActive is how many jobs may run at once.
+	DefaultMaxActive = 16
+)
+
+var (
+	// ErrCancelled is the error of a job cancelled by a client.
+	ErrCancelled = errors.New("job cancelled")
+	// ErrShuttingDown is the error of a job stopped by the server shutting down.
+	ErrShuttingDown = errors.New("server is shutting down")
+	// ErrTooManyJobs is returned by Submit when MaxActive jobs are running.
+	ErrTooManyJobs = errors.New("too many jobs are running")
+)
+
+// FinishFunc is called once a job has finished. ctx keeps the values of the
+// context the job was submitted with but isn't cancelled with the job.
+type FinishFunc func(ctx context.Context, job Snapshot)
+
+// Task does a job's work. It reports how many of the job's steps are done
+// through progress and should return soon after ctx is done.
+type Task func(ctx context.Context, progress func(processed int)) (any, error)
+
+// Snapshot is the state of a job at one point in time.
+type Snapshot struct {
+	ID         string
+	Owner      string // Who submitted the job; only they can see it
+	Status     Status
+	Processed  int
+	Total      int
+	Result     any   // Only set once the job has succeeded
+	Err        error // Only set once the job has failed or been cancelled
+	CreatedAt  time.Time
+	FinishedAt time.Time         // Zero while the job is running
+	Deliveries []webhook.Attempt // Attempts to deliver the job's completion callback
+}
+
+type job struct {
+	snapshot Snapshot
+	cancel   context.CancelCauseFunc
+}
+
+// Manager runs jobs and keeps them until Retention after they finish.
+type Manager struct {
+	Retention time.Duration
+	// MaxActive is how many jobs may run at once; 0 allows any number
+	MaxActive int
+
+	base context.Context // Done once the server shuts down
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	active int // Jobs that haven't finished yet
+}
+
+// NewManager returns a Manager whose jobs are cancelled once ctx is done.
+func NewManager(ctx context.Context) *Manager {
+	base, stop := context.WithCancelCause(context.Background())
+	context.AfterFunc(ctx, func() { stop(ErrShuttingDown) })
+	return &Manager{
+		Retention: DefaultRetention,
+		MaxActive: DefaultMaxActive,
+		base:      base,
+		jobs:      make(map[string]*job),
+	}
+}
+
+// Submit starts task as a new job of owner's with total steps, calling
+// onFinish, if set, once it has finished. The job keeps ctx's values, such as
+// its trace, but not its cancellation, so it outlives the request that
+// submitted it. Submit returns ErrTooManyJobs when MaxActive jobs are running.
+func (m *Manager) Submit(ctx context.Context, owner string, total int, task Task, onFinish FinishFunc) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.MaxActive > 0 && m.active >= m.MaxActive {
+		return Snapshot{}, ErrTooManyJobs
+	}
+	m.prune()
+
+	jobCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	stopWithManager := context.AfterFunc(m.base, func() { cancel(context.Cause(m.base)) })
+	j := &job{
+		snapshot: Snapshot{ID: newID(), Owner: owner, Status: StatusRunning, Total: total, CreatedAt: time.Now()},
+		cancel:   cancel,
+	}
+	m.jobs[j.snapshot.ID] = j
+	m.active++
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer stopWithManager()
+		defer cancel(nil)
+
+		result, err := task(jobCtx, func(processed int) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			j.snapshot.Processed = processed
+		})
+		job := m.finish(jobCtx, j, result, err)
+		if onFinish != nil {
+			onFinish(context.WithoutCancel(jobCtx), job)
+		}
+	}()
+	return j.snapshot, nil
+}
+
+func (m *Manager) finish(ctx context.Context, j *job, result any, err error) Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active--
+	j.snapshot.FinishedAt = time.Now()
+	switch {
+	case ctx.Err() != nil:
+		j.snapshot.Status = StatusCancelled
+		j.snapshot.Err = context.Cause(ctx)
+	case err != nil:
+		j.snapshot.Status = StatusFailed
+		j.snapshot.Err = err
+	default:
+		j.snapshot.Status = StatusSucceeded
+		j.snapshot.Result = result
+	}
+	return j.snapshot
+}
+
+// Get returns owner's job with the given ID, if it exists. Other owners'
+// jobs don't exist for owner.
+func (m *Manager) Get(id string, owner string) (Snapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, exists := m.lookup(id, owner)
+	if !exists {
+		return Snapshot{}, false
+	}
+	return j.snapshot, true
+}
+
+// Cancel asks owner's running job to stop. The job is cancelled once its
+// task returns; cancelling a finished job does nothing.
+func (m *Manager) Cancel(id string, owner string) (Snapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, exists := m.lookup(id, owner)
+	if !exists {
+		return Snapshot{}, false
+	}
+	j.cancel(ErrCancelled)
+	return j.snapshot, true
+}
+
+// lookup returns owner's job with the given ID. m.mu must be held.
+func (m *Manager) lookup(id string, owner string) (*job, bool) {
+	j, exists := m.jobs[id]
+	if !exists || j.snapshot.Owner != owner {
+		return nil, false
+	}
+	return j, true
+}
+
+// RecordDelivery adds an attempt to deliver a job's completion callback.
+func (m *Manager) RecordDelivery(id string, attempt webhook.Attempt) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j, exists := m.jobs[id]; exists {
+		j.snapshot.Deliveries = append(j.snapshot.Deliveries, attempt)
+	}
+}
+
+// Wait blocks until every job's task, and its onFinish, has returned.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// prune drops jobs that finished more than Retention ago. m.mu must be held.
+func (m *Manager) prune() {
+	for id, j := range m.jobs {
+		if !j.snapshot.FinishedAt.IsZero() && time.Since(j.snapshot.FinishedAt) > m.Retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,251 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often a token with an unknown key ID can
+// make a KeySet fetch its keys again.
+const minRefreshInterval = time.Minute
+
+// publicKey is a verification key from a JWKS.
+type publicKey struct {
+	key crypto.PublicKey
+	alg string // Empty if the JWK doesn't restrict it
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the signing keys of a JSON Web Key Set by key ID. Keys for
+// encryption and of unsupported types are skipped.
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = publicKey{key: key, alg: k.Alg}
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid RSA key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y: %w", err)
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+	}
+	return key, nil
+}
+
+// KeySet holds the keys tokens are verified with, fetched from a JWKS file
+// or URL.
+type KeySet struct {
+	fetch func(ctx context.Context) ([]byte, error)
+
+	mu          sync.Mutex
+	keys        map[string]publicKey
+	lastRefresh time.Time
+	refreshing  *refreshCall // Non-nil while the keys are being fetched
+}
+
+// refreshCall is a fetch of the keys that concurrent refreshes share.
+type refreshCall struct {
+	done chan struct{}
+	err  error // Set before done is closed
+}
+
+// NewFileKeySet returns a KeySet reading the JWKS file at path.
+func NewFileKeySet(path string) *KeySet {
+	return &KeySet{fetch: func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}}
+}
+
+// NewURLKeySet returns a KeySet fetching the JWKS at url, such as an
+// identity provider's /.well-known/jwks.json.
+func NewURLKeySet(url string, client *http.Client) *KeySet {
+	return &KeySet{fetch: func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching JWKS: %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}}
+}
+
+// Refresh fetches the keys again, keeping the previous keys if it fails. A
+// refresh already in progress is waited for instead of starting another.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	call := s.startRefresh(ctx)
+	s.mu.Unlock()
+	return call.wait(ctx)
+}
+
+// startRefresh returns the refresh in progress, or starts one. s.mu must be
+// held. The keys are fetched without it, so tokens signed with known keys
+// are verified while a refresh is slow.
+func (s *KeySet) startRefresh(ctx context.Context) *refreshCall {
+	if s.refreshing != nil {
+		return s.refreshing
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	s.refreshing = call
+	s.lastRefresh = time.Now()
+	// Detached from ctx, so a caller giving up doesn't fail the refresh for
+	// everyone waiting on it
+	go func() {
+		keys, err := s.load(context.WithoutCancel(ctx))
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+		}
+		s.refreshing = nil
+		s.mu.Unlock()
+		call.err = err
+		close(call.done)
+	}()
+	return call
+}
+
+// load fetches and parses the keys.
+func (s *KeySet) load(ctx context.Context) (map[string]publicKey, error) {
+	data, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// wait waits for the refresh to finish or ctx to be done.
+func (c *refreshCall) wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// key returns the key with the given ID. An unknown ID fetches the keys
+// again, in case the issuer has rotated them, at most once a minute. A
+// token without an ID can only use a set's only key.
+func (s *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	s.mu.Lock()
+	key, found := s.lookup(kid)
+	if found || (s.refreshing == nil && time.Since(s.lastRefresh) < minRefreshInterval) {
+		s.mu.Unlock()
+		if !found {
+			return publicKey{}, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return key, nil
+	}
+	call := s.startRefresh(ctx)
+	s.mu.Unlock()
+
+	if err := call.wait(ctx); err != nil {
+		return publicKey{}, fmt.Errorf("refreshing JWKS: %w", err)
+	}
+	s.mu.Lock()
+	key, found = s.lookup(kid)
+	s.mu.Unlock()
+	if !found {
+		return publicKey{}, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+// lookup returns the key with the given ID. s.mu must be held.
+func (s *KeySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, found := s.keys[kid]
+	return key, found
+}
@@ -0,0 +1,246 @@
+// Package jwtauth verifies bearer JWTs, such as the Clerk session tokens the
+// main API uses, and maps their claims to API scopes.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers the hashes signatures are checked with
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"mmr/backend/apikey"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultScopesClaim is the claim scopes are read from, as a space-separated
+// string or an array of strings, unless configured otherwise.
+const DefaultScopesClaim = "scope"
+
+// DefaultLeeway is how far clocks may drift between the issuer and this
+// service when checking a token's exp and nbf.
+const DefaultLeeway = 30 * time.Second
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// algorithms are the supported JWS algorithms and the hashes they sign.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+// Verifier checks bearer JWTs signed by one issuer.
+type Verifier struct {
+	Keys     *KeySet
+	Issuer   string // Required iss claim
+	Audience string // Required in the aud claim; not checked if empty
+	// ScopesClaim names the claim that grants scopes; unknown scopes in it
+	// are ignored
+	ScopesClaim string
+	// DefaultScopes are granted to every valid token, e.g. predict so the
+	// frontend can call read-only endpoints
+	DefaultScopes []apikey.Scope
+	Leeway        time.Duration
+}
+
+// NewVerifier returns a Verifier with the default scopes claim and leeway.
+func NewVerifier(keys *KeySet, issuer string, audience string, defaultScopes []apikey.Scope) *Verifier {
+	return &Verifier{
+		Keys:          keys,
+		Issuer:        issuer,
+		Audience:      audience,
+		ScopesClaim:   DefaultScopesClaim,
+		DefaultScopes: defaultScopes,
+		Leeway:        DefaultLeeway,
+	}
+}
+
+// Principal is who a verified token was issued to.
+type Principal struct {
+	Subject string
+	Scopes  []apikey.Scope
+}
+
+// Allows reports whether the principal was granted scope.
+func (p Principal) Allows(scope apikey.Scope) bool {
+	return apikey.Allowed(p.Scopes, scope)
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience is the aud claim, which is either a string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+// Verify checks token's signature, issuer, audience and lifetime, and
+// returns who it was issued to. Every error wraps ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (Principal, error) {
+	principal, err := v.verify(ctx, token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return principal, nil
+}
+
+func (v *Verifier) verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Principal{}, fmt.Errorf("malformed header: %w", err)
+	}
+	hash, supported := algorithms[h.Alg]
+	if !supported {
+		return Principal{}, fmt.Errorf("unsupported algorithm %q", h.Alg)
+	}
+	key, err := v.Keys.key(ctx, h.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	if key.alg != "" && key.alg != h.Alg {
+		return Principal{}, fmt.Errorf("key %q is for %s, not %s", h.Kid, key.alg, h.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("malformed signature: %w", err)
+	}
+	if err := verifySignature(key.key, h.Alg, hash, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Principal{}, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := v.checkClaims(c); err != nil {
+		return Principal{}, err
+	}
+
+	var all map[string]any
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return Principal{}, fmt.Errorf("malformed claims: %w", err)
+	}
+	return Principal{Subject: c.Subject, Scopes: v.scopes(all)}, nil
+}
+
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, signed string, signature []byte) error {
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("%s needs an EC key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			return fmt.Errorf("bad signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return fmt.Errorf("bad signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("bad signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+func (v *Verifier) checkClaims(c claims) error {
+	if c.Issuer != v.Issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.Audience != "" && !slices.Contains(c.Audience, v.Audience) {
+		return fmt.Errorf("token is not for audience %q", v.Audience)
+	}
+
+	now := time.Now()
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(v.Leeway)) {
+		return fmt.Errorf("token has expired")
+	}
+	if c.NotBefore != nil && now.Add(v.Leeway).Before(unixTime(*c.NotBefore)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// scopes returns the default scopes and those granted by the scopes claim.
+func (v *Verifier) scopes(all map[string]any) []apikey.Scope {
+	scopes := slices.Clone(v.DefaultScopes)
+	var granted []string
+	switch claim := all[v.ScopesClaim].(type) {
+	case string:
+		granted = strings.Fields(claim)
+	case []any:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				granted = append(granted, s)
+			}
+		}
+	}
+	for _, name := range granted {
+		scope := apikey.Scope(name)
+		if scope.Valid() && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -2,13 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"mmr/backend/apikey"
 	"mmr/backend/config"
+	"mmr/backend/jwtauth"
+	"mmr/backend/mmr"
 	server "mmr/backend/server"
 	"mmr/backend/telemetry"
 )
@@ -17,12 +24,40 @@ import (
 
 var version = "dev"
 
+// apiKeysReloadInterval is how often the API keys file is checked for changes.
+const apiKeysReloadInterval = 30 * time.Second
+
 func main() {
-	config.LoadEnv()
+	cfg := config.LoadEnv()
+
+	if cfg.RatingProfilesFile != "" {
+		if err := mmr.LoadProfiles(cfg.RatingProfilesFile); err != nil {
+			slog.Error("loading rating profiles failed", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("loaded rating profiles", "profiles", mmr.ProfileNames())
+	}
+
+	apiKeys, err := loadAPIKeys(cfg)
+	if err != nil {
+		slog.Error("loading API keys failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("loaded API keys", "keys", apiKeys.Names())
+
+	tokens, err := loadTokenVerifier(context.Background(), cfg)
+	if err != nil {
+		slog.Error("loading JWT verification keys failed", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	if cfg.APIKeysFile != "" {
+		go apiKeys.Watch(ctx, apiKeysReloadInterval)
+	}
+
 	shutdown, err := telemetry.Init(ctx, version)
 	if err != nil {
 		slog.Error("telemetry init failed", "error", err)
@@ -36,7 +71,65 @@ func main() {
 		}
 	}()
 
-	if err := server.Init(ctx); err != nil {
+	if err := server.Init(ctx, cfg, apiKeys, tokens); err != nil {
 		slog.Error("server stopped with error", "error", err)
 	}
 }
+
+// loadAPIKeys returns the keys from the API keys file, if any, and the
+// legacy ADMIN_SECRET.
+func loadAPIKeys(cfg config.Config) (*apikey.Store, error) {
+	if cfg.AdminSecret == "" && cfg.APIKeysFile == "" {
+		return nil, errors.New("either API_KEYS_FILE or ADMIN_SECRET must be set")
+	}
+
+	var static []apikey.Key
+	if cfg.AdminSecret != "" {
+		key := apikey.NewKey("admin-secret", cfg.AdminSecret, []apikey.Scope{apikey.ScopeAdmin}, time.Time{})
+		key.WebhookSecret = []byte(cfg.WebhookSecret)
+		static = append(static, key)
+	}
+	keys := apikey.NewStore(static...)
+	if cfg.APIKeysFile != "" {
+		if err := keys.LoadFile(cfg.APIKeysFile); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// loadTokenVerifier returns a verifier for bearer JWTs, or nil if JWT_ISSUER
+// isn't set.
+func loadTokenVerifier(ctx context.Context, cfg config.Config) (*jwtauth.Verifier, error) {
+	if cfg.JWTIssuer == "" {
+		return nil, nil
+	}
+
+	var keys *jwtauth.KeySet
+	switch {
+	case cfg.JWTJWKSURL != "" && cfg.JWTJWKSFile != "":
+		return nil, errors.New("only one of JWT_JWKS_URL and JWT_JWKS_FILE may be set")
+	case cfg.JWTJWKSURL != "":
+		keys = jwtauth.NewURLKeySet(cfg.JWTJWKSURL, &http.Client{Timeout: 10 * time.Second})
+	case cfg.JWTJWKSFile != "":
+		keys = jwtauth.NewFileKeySet(cfg.JWTJWKSFile)
+	default:
+		return nil, errors.New("JWT_ISSUER needs JWT_JWKS_URL or JWT_JWKS_FILE")
+	}
+	if err := keys.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	defaultScopes := make([]apikey.Scope, len(cfg.JWTDefaultScopes))
+	for i, name := range cfg.JWTDefaultScopes {
+		defaultScopes[i] = apikey.Scope(strings.TrimSpace(name))
+		if !defaultScopes[i].Valid() {
+			return nil, fmt.Errorf("JWT_DEFAULT_SCOPES has unknown scope %q", name)
+		}
+	}
+	tokens := jwtauth.NewVerifier(keys, cfg.JWTIssuer, cfg.JWTAudience, defaultScopes)
+	if cfg.JWTScopesClaim != "" {
+		tokens.ScopesClaim = cfg.JWTScopesClaim
+	}
+	return tokens, nil
+}
@@ -39,7 +39,7 @@ func AccessLog() gin.HandlerFunc {
 			size = 0
 		}
 
-		slog.LogAttrs(c.Request.Context(), level, "http.request",
+		attrs := []slog.Attr{
 			slog.String("http.request.method", c.Request.Method),
 			slog.String("http.route", route),
 			slog.String("url.path", path),
@@ -47,6 +47,13 @@ func AccessLog() gin.HandlerFunc {
 			slog.Duration("duration", time.Since(start)),
 			slog.String("client.address", c.ClientIP()),
 			slog.Int("http.response.body.size", size),
-		)
+		}
+		// Set by the auth middleware once a request has authenticated
+		for _, key := range []string{KeyNameKey, SubjectKey} {
+			if name := c.GetString(key); name != "" {
+				attrs = append(attrs, slog.String(key, name))
+			}
+		}
+		slog.LogAttrs(c.Request.Context(), level, "http.request", attrs...)
 	}
 }
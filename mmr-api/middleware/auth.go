@@ -1,13 +1,39 @@
 package middleware
 
 import (
+	"bytes"
 	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mmr/backend/apikey"
+	"mmr/backend/jwtauth"
+	"mmr/backend/signing"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// KeyNameKey is the gin context key holding the name of the API key a
+	// request authenticated with.
+	KeyNameKey = "auth.key.name"
+	// SubjectKey is the gin context key holding the subject of the bearer
+	// token a request authenticated with.
+	SubjectKey = "auth.subject"
+)
+
+// RequireAdminAuth compares X-API-KEY against the ADMIN_SECRET environment
+// variable.
+//
+// Deprecated: use RequireAPIKey, which supports several named and scoped
+// keys. ADMIN_SECRET is still accepted there as an admin key.
 func RequireAdminAuth(c *gin.Context) {
 	apiKey := c.GetHeader("X-API-KEY")
 	secret := os.Getenv("ADMIN_SECRET")
@@ -20,3 +46,166 @@ func RequireAdminAuth(c *gin.Context) {
 
 	c.Next()
 }
+
+// RequireAPIKey authenticates X-API-KEY against keys and requires the key to
+// grant scope. The key's name is attached to the request's span and access
+// log.
+func RequireAPIKey(keys *apikey.Store, scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := keys.Authenticate(c.GetHeader("X-API-KEY"))
+		if err != nil {
+			if !errors.Is(err, apikey.ErrUnknownKey) {
+				// Worth knowing during a rotation or after a leak, unlike
+				// unknown keys
+				slog.WarnContext(c.Request.Context(), "API key refused", "auth.key.name", key.Name, "error", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		setKey(c, key)
+		if !key.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireBearer authenticates an "Authorization: Bearer" JWT with tokens
+// and requires the token to grant scope. The token's subject is attached to
+// the request's span and access log.
+func RequireBearer(tokens *jwtauth.Verifier, scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := tokens.Verify(c.Request.Context(), bearerToken(c))
+		if err != nil {
+			slog.InfoContext(c.Request.Context(), "bearer token rejected", "error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		setPrincipal(c, SubjectKey, principal.Subject)
+		if !principal.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSignedRequest verifies a request signed with an API key in keys,
+// as described in package signing, and requires the key to grant scope.
+// Requests signed too long ago and replayed nonces are refused. The key's
+// name is attached to the request's span and access log.
+func RequireSignedRequest(keys *apikey.Store, nonces *signing.NonceCache, scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := verifySignature(c, keys, nonces)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			slog.InfoContext(c.Request.Context(), "signed request rejected", "auth.key.name", key.Name, "error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		setKey(c, key)
+		if !key.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func verifySignature(c *gin.Context, keys *apikey.Store, nonces *signing.NonceCache) (apikey.Key, error) {
+	key, err := keys.Lookup(c.GetHeader(signing.KeyIDHeader))
+	if err != nil {
+		return key, err
+	}
+	signingKey, err := key.SigningKey()
+	if err != nil {
+		return key, err
+	}
+	header := c.GetHeader(signing.TimestampHeader)
+	timestamp, err := signing.ParseTimestamp(header)
+	if err != nil {
+		return key, err
+	}
+	nonce := c.GetHeader(signing.NonceHeader)
+	if nonce == "" || len(nonce) > signing.MaxNonceLength {
+		return key, fmt.Errorf("nonce must be 1 to %d characters", signing.MaxNonceLength)
+	}
+
+	// The body is buffered to check the signature, so it is bounded even on
+	// routes that stream theirs
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, signing.MaxBodySize))
+	if err != nil {
+		return key, err
+	}
+	// Let the handler read the body again
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if !signing.Verify(signingKey, header, nonce, c.Request.Method, c.Request.URL.RequestURI(), body, c.GetHeader(signing.SignatureHeader)) {
+		return key, fmt.Errorf("bad signature")
+	}
+	// Only after the signature is checked, so forged requests can't fill the
+	// nonce cache
+	return key, nonces.Use(key.Name, nonce, timestamp, time.Now())
+}
+
+// Authenticator accepts every configured kind of credential.
+type Authenticator struct {
+	Keys   *apikey.Store
+	Tokens *jwtauth.Verifier   // Nil refuses bearer tokens
+	Nonces *signing.NonceCache // Nil refuses signed requests
+}
+
+// Require authenticates a request with RequireSignedRequest when it has a
+// signature, RequireBearer when it has a bearer JWT and RequireAPIKey
+// otherwise, and requires the credential to grant scope.
+func (a Authenticator) Require(scope apikey.Scope) gin.HandlerFunc {
+	requireAPIKey := RequireAPIKey(a.Keys, scope)
+	var requireBearer, requireSigned gin.HandlerFunc
+	if a.Tokens != nil {
+		requireBearer = RequireBearer(a.Tokens, scope)
+	}
+	if a.Nonces != nil {
+		requireSigned = RequireSignedRequest(a.Keys, a.Nonces, scope)
+	}
+	return func(c *gin.Context) {
+		switch {
+		case requireSigned != nil && c.GetHeader(signing.SignatureHeader) != "":
+			requireSigned(c)
+		case requireBearer != nil && bearerToken(c) != "":
+			requireBearer(c)
+		default:
+			requireAPIKey(c)
+		}
+	}
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// setKey records that a request authenticated with key, and passes the key
+// on to handlers in the request's context.
+func setKey(c *gin.Context, key apikey.Key) {
+	setPrincipal(c, KeyNameKey, key.Name)
+	c.Request = c.Request.WithContext(apikey.NewContext(c.Request.Context(), key))
+}
+
+// setPrincipal records who a request authenticated as under key.
+func setPrincipal(c *gin.Context, key string, name string) {
+	c.Set(key, name)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String(key, name))
+}
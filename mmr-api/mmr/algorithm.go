@@ -0,0 +1,128 @@
+package mmr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/intinig/go-openskill/types"
+)
+
+// RatingAlgorithm rates a single match between teams. Rate returns the teams
+// in the order they were given, with every player's rating replaced by its
+// post-match value, or an error if the algorithm can't rate the match.
+type RatingAlgorithm interface {
+	Name() string
+	// NewRating is the rating of a player the request has no rating for.
+	NewRating() types.Rating
+	Rate(teams []TeamV2) ([]TeamV2, error)
+	// DisplayValue converts a rating to the MMR shown to players.
+	DisplayValue(rating types.Rating) float64
+}
+
+const (
+	AlgorithmPlackettLuce           = "plackett-luce"
+	AlgorithmBradleyTerryFull       = "bradley-terry-full"
+	AlgorithmBradleyTerryPart       = "bradley-terry-part"
+	AlgorithmThurstoneMostellerFull = "thurstone-mosteller-full"
+	AlgorithmThurstoneMostellerPart = "thurstone-mosteller-part"
+	AlgorithmElo                    = "elo"
+	AlgorithmGlicko2                = "glicko2"
+
+	// DefaultAlgorithm is used when a request doesn't name an algorithm.
+	DefaultAlgorithm = AlgorithmPlackettLuce
+)
+
+var algorithms = map[string]RatingAlgorithm{}
+
+func init() {
+	RegisterAlgorithm(openSkillAlgorithm{})
+	RegisterAlgorithm(wengLinAlgorithm{name: AlgorithmBradleyTerryFull, pairs: allPairs, update: bradleyTerryUpdate})
+	RegisterAlgorithm(wengLinAlgorithm{name: AlgorithmBradleyTerryPart, pairs: adjacentPairs, update: bradleyTerryUpdate})
+	RegisterAlgorithm(wengLinAlgorithm{name: AlgorithmThurstoneMostellerFull, pairs: allPairs, update: thurstoneMostellerUpdate})
+	RegisterAlgorithm(wengLinAlgorithm{name: AlgorithmThurstoneMostellerPart, pairs: adjacentPairs, update: thurstoneMostellerUpdate})
+	RegisterAlgorithm(eloAlgorithm{})
+	RegisterAlgorithm(glicko2Algorithm{})
+}
+
+// RegisterAlgorithm makes an algorithm selectable by name. It is meant to be
+// called from init functions and panics on duplicate names.
+func RegisterAlgorithm(algorithm RatingAlgorithm) {
+	name := algorithm.Name()
+	if _, exists := algorithms[name]; exists {
+		panic(fmt.Sprintf("mmr: rating algorithm %q registered twice", name))
+	}
+	algorithms[name] = algorithm
+}
+
+// AlgorithmByName looks up a registered algorithm. An empty name selects
+// DefaultAlgorithm.
+func AlgorithmByName(name string) (RatingAlgorithm, error) {
+	if name == "" {
+		name = DefaultAlgorithm
+	}
+	algorithm, exists := algorithms[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown algorithm %q, expected one of: %s", name, strings.Join(AlgorithmNames(), ", "))
+	}
+	return algorithm, nil
+}
+
+// AlgorithmNames returns the names of all registered algorithms, sorted.
+func AlgorithmNames() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RateOptions are adjustments Rate applies on top of any algorithm.
+type RateOptions struct {
+	// Margin scales mu changes by the winning margin when set.
+	Margin *MarginModel
+}
+
+// weightAware is implemented by algorithms that pass player weights to
+// their rating engine, so a part-time player counts less towards their
+// team's strength as well as moving less.
+type weightAware interface {
+	accountsForWeight()
+}
+
+// Rate rates a match with algorithm and applies options. Algorithms that
+// aren't weightAware have every player's rating change scaled by their Weight
+// afterwards, so a substitute who played half the match moves half as far.
+func Rate(algorithm RatingAlgorithm, teams []TeamV2, options RateOptions) ([]TeamV2, error) {
+	muScale := 1.0
+	if options.Margin != nil {
+		if _, ok := algorithm.(marginAware); ok {
+			return nil, fmt.Errorf("%s already accounts for the score margin and can't be combined with a margin model", algorithm.Name())
+		}
+		muScale = options.Margin.MatchMultiplier(teams)
+	}
+
+	rated, err := algorithm.Rate(teams)
+	if err != nil {
+		return nil, err
+	}
+
+	_, weighted := algorithm.(weightAware)
+	for i, team := range teams {
+		for j, before := range team.Players {
+			weight := before.weight()
+			if weighted {
+				weight = 1
+			}
+			if weight == 1 && muScale == 1 {
+				continue
+			}
+			after := &rated[i].Players[j]
+			after.Player.Mu = before.Player.Mu + weight*muScale*(after.Player.Mu-before.Player.Mu)
+			after.Player.Sigma = before.Player.Sigma + weight*(after.Player.Sigma-before.Player.Sigma)
+			after.Volatility = before.Volatility + weight*(after.Volatility-before.Volatility)
+		}
+	}
+	return rated, nil
+}
@@ -0,0 +1,74 @@
+package mmr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxBalancePoolSize bounds the players BalanceTeams accepts. Every split is
+// scored, and a pool of 20 already has 92378 of them.
+const MaxBalancePoolSize = 20
+
+// TeamSplit is one way of dividing a pool into two teams.
+type TeamSplit struct {
+	Teams        []TeamV2
+	MatchQuality float64
+}
+
+// BalanceTeams divides players into two teams of teamSize and returns the
+// top splits by MatchQuality, best first. Players keep their pool order
+// within a team and the first player is always on the first team, so the
+// same input always gives the same output; equally good splits are returned
+// in the order they were found.
+func BalanceTeams(players []PlayerV2, teamSize, top int) ([]TeamSplit, error) {
+	if teamSize < 1 {
+		return nil, fmt.Errorf("teamSize must be at least 1")
+	}
+	if len(players) != 2*teamSize {
+		return nil, fmt.Errorf("a pool of %d players can't be split into two teams of %d", len(players), teamSize)
+	}
+	if len(players) > MaxBalancePoolSize {
+		return nil, fmt.Errorf("a pool can have at most %d players, got %d", MaxBalancePoolSize, len(players))
+	}
+	if top < 1 {
+		return nil, fmt.Errorf("top must be at least 1")
+	}
+
+	var splits []TeamSplit
+	inFirstTeam := make([]bool, len(players))
+	inFirstTeam[0] = true
+	var choose func(next, remaining int)
+	choose = func(next, remaining int) {
+		if remaining == 0 {
+			splits = append(splits, newTeamSplit(players, inFirstTeam))
+			return
+		}
+		for i := next; i <= len(players)-remaining; i++ {
+			inFirstTeam[i] = true
+			choose(i+1, remaining-1)
+			inFirstTeam[i] = false
+		}
+	}
+	choose(1, teamSize-1)
+
+	sort.SliceStable(splits, func(i, j int) bool {
+		return splits[i].MatchQuality > splits[j].MatchQuality
+	})
+	if top < len(splits) {
+		splits = splits[:top]
+	}
+	return splits, nil
+}
+
+func newTeamSplit(players []PlayerV2, inFirstTeam []bool) TeamSplit {
+	var first, second TeamV2
+	for i, player := range players {
+		if inFirstTeam[i] {
+			first.Players = append(first.Players, player)
+		} else {
+			second.Players = append(second.Players, player)
+		}
+	}
+	teams := []TeamV2{first, second}
+	return TeamSplit{Teams: teams, MatchQuality: MatchQuality(teams)}
+}
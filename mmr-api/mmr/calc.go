@@ -1,6 +1,8 @@
 package mmr
 
 import (
+	"math"
+
 	"github.com/intinig/go-openskill/ptr"
 	"github.com/intinig/go-openskill/rating"
 	"github.com/intinig/go-openskill/types"
@@ -32,54 +34,128 @@ func CalculateNewMMR(team1 *Team, team2 *Team) (Team, Team) {
 }
 
 func CalculateNewMMRV2(team1 *TeamV2, team2 *TeamV2) (TeamV2, TeamV2) {
-	team1Ratings := make(types.Team, len(team1.Players))
-	for i, p := range team1.Players {
-		team1Ratings[i] = p.Player
+	rated, _ := openSkillAlgorithm{}.Rate([]TeamV2{*team1, *team2})
+	*team1, *team2 = rated[0], rated[1]
+
+	return *team1, *team2
+}
+
+// openSkillScale provides the mu/sigma defaults and display value shared by
+// OpenSkill and the other Weng-Lin models. Nil values use the defaults; a
+// Profile sets the others.
+type openSkillScale struct {
+	newRating         startingRating
+	beta              *float64
+	tau               *float64
+	displayMultiplier *float64
+}
+
+func newOpenSkillScale(profile Profile) openSkillScale {
+	return openSkillScale{
+		newRating:         profile.startingRating(),
+		beta:              profile.Beta,
+		tau:               profile.Tau,
+		displayMultiplier: profile.DisplayMultiplier,
 	}
-	team2Ratings := make(types.Team, len(team2.Players))
-	for i, p := range team2.Players {
-		team2Ratings[i] = p.Player
+}
+
+func (s openSkillScale) NewRating() types.Rating {
+	return s.newRating.or(NewDefaultRating())
+}
+
+func (s openSkillScale) DisplayValue(rating types.Rating) float64 {
+	if s.displayMultiplier == nil {
+		return RankingDisplayValue(rating.Mu, rating.Sigma)
 	}
+	return displayValue(rating.Mu, rating.Sigma, *s.displayMultiplier)
+}
 
-	ratingResults := rating.Rate([]types.Team{team1Ratings, team2Ratings}, &types.OpenSkillOptions{
-		Score: []int{int(team1.Score), int(team2.Score)}, // it uses these scores to determine the winner
-	})
+func (s openSkillScale) betaOrDefault() float64 {
+	if s.beta == nil {
+		return wengLinBeta
+	}
+	return *s.beta
+}
+
+// addDynamics grows every player's sigma by tau before a match so ratings
+// never become completely fixed. Without a tau the teams are returned as is.
+func (s openSkillScale) addDynamics(teams []TeamV2) []TeamV2 {
+	if s.tau == nil || *s.tau == 0 {
+		return teams
+	}
+	tau := *s.tau
+
+	dynamic := make([]TeamV2, len(teams))
+	for i, team := range teams {
+		ratings := team.ratings()
+		for j := range ratings {
+			ratings[j].Sigma = math.Sqrt(ratings[j].Sigma*ratings[j].Sigma + tau*tau)
+		}
+		dynamic[i] = team.withRatings(ratings)
+	}
+	return dynamic
+}
+
+// openSkillAlgorithm is OpenSkill's default Plackett-Luce model.
+type openSkillAlgorithm struct {
+	openSkillScale
+}
+
+func (openSkillAlgorithm) Name() string {
+	return AlgorithmPlackettLuce
+}
+
+func (a openSkillAlgorithm) withProfile(profile Profile) RatingAlgorithm {
+	a.openSkillScale = newOpenSkillScale(profile)
+	return a
+}
+
+// OpenSkill weighs every player's contribution to their team and update.
+func (openSkillAlgorithm) accountsForWeight() {}
 
-	for i := range team1.Players {
-		team1.Players[i].Player = ratingResults[0][i]
+func (a openSkillAlgorithm) Rate(teams []TeamV2) ([]TeamV2, error) {
+	teamRatings := make([]types.Team, len(teams))
+	scores := make([]int, len(teams))
+	weights := make([][]float64, len(teams))
+	for i, team := range teams {
+		teamRatings[i] = team.ratings()
+		scores[i] = int(team.Score)
+		weights[i] = team.weights()
 	}
-	for i := range team2.Players {
-		team2.Players[i].Player = ratingResults[1][i]
+
+	options := &types.OpenSkillOptions{
+		Score:   scores, // it uses these scores to determine the winner
+		Weights: weights,
+	}
+	if a.beta != nil {
+		options.Beta = a.beta
+	}
+	if a.tau != nil {
+		options.Tau = a.tau
 	}
+	ratingResults := rating.Rate(teamRatings, options)
 
-	return *team1, *team2
+	rated := make([]TeamV2, len(teams))
+	for i, team := range teams {
+		rated[i] = team.withRatings(ratingResults[i])
+	}
+	return rated, nil
 }
 
 func NewDefaultRating() types.Rating {
-	// Use the New function to get a Rating with default options
-	// TODO: Allow multiple options via "algorithm" field in the request
+	// Use the New function to get a Rating with default options. The
+	// Weng-Lin and OpenSkill algorithms rate on this mu/sigma scale; elo and
+	// glicko2 start from their own defaults.
 	return rating.NewWithOptions(&types.OpenSkillOptions{Sigma: ptr.Float64(5)})
 }
 
 func RatingForPlayer(playerRating view.MMRCalculationPlayerRating) types.Rating {
-	if playerRating.IsPreviousSeasonRating != nil && *playerRating.IsPreviousSeasonRating {
-		// Use the previous season's rating as basis for the new rating
-		defaultRating := NewDefaultRating()
-
-		if playerRating.Mu != nil {
-			muDiff := *playerRating.Mu - defaultRating.Mu
-			addedMu := muDiff / 3
-			defaultRating.Mu += addedMu
-		}
-
-		return defaultRating
-	}
+	return RatingForPlayerWithDefault(playerRating, NewDefaultRating())
+}
 
-	// Create Rating with provided Mu and Sigma
-	return rating.NewWithOptions(
-		&types.OpenSkillOptions{
-			Mu:    playerRating.Mu,
-			Sigma: playerRating.Sigma,
-		},
-	)
+// RatingForPlayerWithDefault is RatingForPlayer for algorithms whose new
+// player rating isn't NewDefaultRating; a previous season's rating is carried
+// over relative to defaultRating.
+func RatingForPlayerWithDefault(playerRating view.MMRCalculationPlayerRating, defaultRating types.Rating) types.Rating {
+	return DefaultCarryOver().RatingForPlayer(playerRating, defaultRating)
 }
@@ -0,0 +1,111 @@
+package mmr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/intinig/go-openskill/rating"
+	"github.com/intinig/go-openskill/types"
+	view "mmr/backend/models"
+)
+
+const (
+	// CarryOverFractionOfDelta starts from the default rating plus Fraction of
+	// the previous season's distance from it, with the default sigma.
+	CarryOverFractionOfDelta = "fraction-of-delta"
+	// CarryOverFull keeps the previous mu and multiplies sigma by
+	// SigmaInflation, never past the default sigma.
+	CarryOverFull = "full"
+	// CarryOverHardReset starts every player from the default rating.
+	CarryOverHardReset = "hard-reset"
+	// CarryOverRegressToMean keeps Fraction of the previous season's distance
+	// from LeagueMean, with the default sigma.
+	CarryOverRegressToMean = "regress-to-mean"
+
+	DefaultCarryOverPolicy   = CarryOverFractionOfDelta
+	DefaultCarryOverFraction = 1.0 / 3
+	DefaultSigmaInflation    = 1.5
+)
+
+// CarryOverPolicy decides the starting rating of a player whose rating is
+// from a previous season.
+type CarryOverPolicy struct {
+	Name           string
+	Fraction       float64
+	SigmaInflation float64
+	LeagueMean     float64
+}
+
+// NewCarryOverPolicy returns a policy with defaults filled in for an empty
+// name and nil parameters. leagueMean is required by CarryOverRegressToMean
+// and ignored by the other policies.
+func NewCarryOverPolicy(name string, fraction, sigmaInflation, leagueMean *float64) (CarryOverPolicy, error) {
+	policy := CarryOverPolicy{Name: name, Fraction: DefaultCarryOverFraction, SigmaInflation: DefaultSigmaInflation}
+	if policy.Name == "" {
+		policy.Name = DefaultCarryOverPolicy
+	}
+	if fraction != nil {
+		policy.Fraction = *fraction
+	}
+	if sigmaInflation != nil {
+		policy.SigmaInflation = *sigmaInflation
+	}
+
+	switch policy.Name {
+	case CarryOverFractionOfDelta, CarryOverFull, CarryOverHardReset:
+	case CarryOverRegressToMean:
+		if leagueMean == nil {
+			return CarryOverPolicy{}, fmt.Errorf("carry-over policy %s needs a league mean", CarryOverRegressToMean)
+		}
+		policy.LeagueMean = *leagueMean
+	default:
+		return CarryOverPolicy{}, fmt.Errorf("unknown carry-over policy %q, expected %s, %s, %s or %s", policy.Name, CarryOverFractionOfDelta, CarryOverFull, CarryOverHardReset, CarryOverRegressToMean)
+	}
+	if !(policy.Fraction >= 0 && policy.Fraction <= 1) {
+		return CarryOverPolicy{}, fmt.Errorf("carry-over fraction must be between 0 and 1")
+	}
+	if !(policy.SigmaInflation >= 1) {
+		return CarryOverPolicy{}, fmt.Errorf("carry-over sigma inflation must be at least 1")
+	}
+	return policy, nil
+}
+
+// DefaultCarryOver is the policy used when a request doesn't choose one.
+func DefaultCarryOver() CarryOverPolicy {
+	return CarryOverPolicy{Name: DefaultCarryOverPolicy, Fraction: DefaultCarryOverFraction, SigmaInflation: DefaultSigmaInflation}
+}
+
+// RatingForPlayer is the starting rating for playerRating. A previous
+// season's rating is carried over by the policy relative to defaultRating;
+// any other rating is used as given.
+func (p CarryOverPolicy) RatingForPlayer(playerRating view.MMRCalculationPlayerRating, defaultRating types.Rating) types.Rating {
+	if playerRating.IsPreviousSeasonRating == nil || !*playerRating.IsPreviousSeasonRating {
+		return rating.NewWithOptions(
+			&types.OpenSkillOptions{
+				Mu:    playerRating.Mu,
+				Sigma: playerRating.Sigma,
+			},
+		)
+	}
+	if playerRating.Mu == nil {
+		return defaultRating
+	}
+
+	previous := *playerRating.Mu
+	switch p.Name {
+	case CarryOverFull:
+		sigma := defaultRating.Sigma
+		if playerRating.Sigma != nil {
+			sigma = math.Min(*playerRating.Sigma*p.SigmaInflation, defaultRating.Sigma)
+		}
+		return types.Rating{Mu: previous, Sigma: sigma}
+	case CarryOverHardReset:
+		return defaultRating
+	case CarryOverRegressToMean:
+		defaultRating.Mu = p.LeagueMean + p.Fraction*(previous-p.LeagueMean)
+		return defaultRating
+	default:
+		defaultRating.Mu += p.Fraction * (previous - defaultRating.Mu)
+		return defaultRating
+	}
+}
@@ -5,6 +5,14 @@ import (
 	"github.com/intinig/go-openskill/types"
 )
 
+// DefaultDisplayMultiplier scales a rating's ordinal to the MMR shown to
+// players.
+const DefaultDisplayMultiplier = 75
+
 func RankingDisplayValue(mu float64, sigma float64) float64 {
-	return rating.Ordinal(rating.NewWithOptions(&types.OpenSkillOptions{Mu: &mu, Sigma: &sigma})) * 75
+	return displayValue(mu, sigma, DefaultDisplayMultiplier)
+}
+
+func displayValue(mu float64, sigma float64, multiplier float64) float64 {
+	return rating.Ordinal(rating.NewWithOptions(&types.OpenSkillOptions{Mu: &mu, Sigma: &sigma})) * multiplier
 }
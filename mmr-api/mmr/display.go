@@ -0,0 +1,121 @@
+package mmr
+
+import (
+	"fmt"
+	"math"
+
+	view "mmr/backend/models"
+)
+
+const (
+	// DisplayLinear shows Scale * MMR + Offset.
+	DisplayLinear = "linear"
+	// DisplayClamped is DisplayLinear kept between Min and Max.
+	DisplayClamped = "clamped"
+	// DisplayPercentile shows the share of the league, from 0 to 100, below
+	// the linear value, assuming values are normally distributed with Mean
+	// and StdDev.
+	DisplayPercentile = "percentile"
+	// DisplayTiers labels the linear value with the highest tier it reaches.
+	DisplayTiers = "tiers"
+)
+
+// DisplayMapping turns the raw MMR an algorithm reports into what players
+// see. Every mapping starts from the linear value Scale * MMR + Offset.
+type DisplayMapping struct {
+	Mapping string
+	Scale   float64
+	Offset  float64
+	Min     float64
+	Max     float64
+	Mean    float64
+	StdDev  float64
+	Tiers   []DisplayTier // Lowest first
+}
+
+// DisplayTier is a named band of display values starting at Min.
+type DisplayTier struct {
+	Name string
+	Min  float64
+}
+
+// DisplayValue is a mapped MMR. Label is only set by DisplayTiers.
+type DisplayValue struct {
+	Value float64
+	Label string
+}
+
+// NewDisplayMapping validates config and fills in defaults: a scale of 1, no
+// offset and, for DisplayClamped, a range from 0 up without a limit.
+func NewDisplayMapping(config view.MMRDisplayMapping) (DisplayMapping, error) {
+	mapping := DisplayMapping{Mapping: config.Mapping, Scale: 1, Max: math.Inf(1)}
+	if config.Scale != nil {
+		mapping.Scale = *config.Scale
+	}
+	if config.Offset != nil {
+		mapping.Offset = *config.Offset
+	}
+	if math.IsNaN(mapping.Scale) || math.IsInf(mapping.Scale, 0) || math.IsNaN(mapping.Offset) || math.IsInf(mapping.Offset, 0) {
+		return DisplayMapping{}, fmt.Errorf("display scale and offset must be finite numbers")
+	}
+
+	switch mapping.Mapping {
+	case DisplayLinear:
+	case DisplayClamped:
+		if config.Min != nil {
+			mapping.Min = *config.Min
+		}
+		if config.Max != nil {
+			mapping.Max = *config.Max
+		}
+		if !(mapping.Min < mapping.Max) {
+			return DisplayMapping{}, fmt.Errorf("display min must be less than max")
+		}
+	case DisplayPercentile:
+		if config.Mean == nil || config.StdDev == nil {
+			return DisplayMapping{}, fmt.Errorf("%s display needs a mean and a stdDev", DisplayPercentile)
+		}
+		mapping.Mean, mapping.StdDev = *config.Mean, *config.StdDev
+		if !(mapping.StdDev > 0) {
+			return DisplayMapping{}, fmt.Errorf("display stdDev must be greater than 0")
+		}
+	case DisplayTiers:
+		if len(config.Tiers) == 0 {
+			return DisplayMapping{}, fmt.Errorf("%s display needs at least one tier", DisplayTiers)
+		}
+		for i, tier := range config.Tiers {
+			if tier.Name == "" {
+				return DisplayMapping{}, fmt.Errorf("display tier %d has no name", i)
+			}
+			if i > 0 && !(tier.Min > config.Tiers[i-1].Min) {
+				return DisplayMapping{}, fmt.Errorf("display tiers must be ordered by increasing min")
+			}
+			mapping.Tiers = append(mapping.Tiers, DisplayTier{Name: tier.Name, Min: tier.Min})
+		}
+	default:
+		return DisplayMapping{}, fmt.Errorf("unknown display mapping %q, expected %s, %s, %s or %s", mapping.Mapping, DisplayLinear, DisplayClamped, DisplayPercentile, DisplayTiers)
+	}
+	return mapping, nil
+}
+
+// Apply maps a raw MMR. Values below the first tier still get its label.
+func (d DisplayMapping) Apply(mmr float64) DisplayValue {
+	value := d.Scale*mmr + d.Offset
+
+	switch d.Mapping {
+	case DisplayClamped:
+		return DisplayValue{Value: math.Min(math.Max(value, d.Min), d.Max)}
+	case DisplayPercentile:
+		return DisplayValue{Value: 100 * normalCDF((value-d.Mean)/d.StdDev)}
+	case DisplayTiers:
+		label := d.Tiers[0].Name
+		for _, tier := range d.Tiers[1:] {
+			if value >= tier.Min {
+				label = tier.Name
+			}
+		}
+		return DisplayValue{Value: value, Label: label}
+	default:
+		return DisplayValue{Value: value}
+	}
+}
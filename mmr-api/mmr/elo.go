@@ -0,0 +1,77 @@
+package mmr
+
+import (
+	"fmt"
+
+	"github.com/intinig/go-openskill/types"
+	"mmr/backend/mmrCustom"
+)
+
+// eloAlgorithm exposes the mmrCustom Elo engine through the RatingAlgorithm
+// interface. The Elo rating travels in Mu and the player's uncertainty in
+// Sigma, so the request and response shapes stay the same.
+type eloAlgorithm struct {
+	// newRating overrides the default starting rating when set by a Profile.
+	newRating startingRating
+}
+
+func (eloAlgorithm) Name() string {
+	return AlgorithmElo
+}
+
+// The Elo engine scales updates by margin already, see mmrCustom.MarginMultiplier.
+func (eloAlgorithm) accountsForMargin() {}
+
+func (a eloAlgorithm) NewRating() types.Rating {
+	return a.newRating.or(types.Rating{Mu: mmrCustom.DefaultMMR, Sigma: mmrCustom.DefaultUncertainty})
+}
+
+func (a eloAlgorithm) withProfile(profile Profile) RatingAlgorithm {
+	a.newRating = profile.startingRating()
+	return a
+}
+
+func (eloAlgorithm) DisplayValue(rating types.Rating) float64 {
+	return rating.Mu
+}
+
+func (eloAlgorithm) Rate(teams []TeamV2) ([]TeamV2, error) {
+	if len(teams) != 2 {
+		return nil, fmt.Errorf("%s only rates matches between two teams, got %d", AlgorithmElo, len(teams))
+	}
+
+	team1, team2 := toCustomTeam(teams[0]), toCustomTeam(teams[1])
+
+	outcome := mmrCustom.Draw
+	if teams[0].Score > teams[1].Score {
+		outcome = mmrCustom.Team1Wins
+	} else if teams[0].Score < teams[1].Score {
+		outcome = mmrCustom.Team2Wins
+	}
+	mmrCustom.UpdateMMRWithMargin(team1, team2, outcome, int(teams[0].Score)-int(teams[1].Score))
+
+	return []TeamV2{fromCustomTeam(teams[0], team1), fromCustomTeam(teams[1], team2)}, nil
+}
+
+func (eloAlgorithm) expectedScore(team, opponent TeamV2) float64 {
+	return mmrCustom.ExpectedScore(
+		mmrCustom.CalculateAverageTeamMMR(toCustomTeam(team)),
+		mmrCustom.CalculateAverageTeamMMR(toCustomTeam(opponent)),
+	)
+}
+
+func toCustomTeam(team TeamV2) *mmrCustom.Team {
+	players := make([]*mmrCustom.Player, len(team.Players))
+	for i, p := range team.Players {
+		players[i] = &mmrCustom.Player{MMR: p.Player.Mu, Uncertainty: p.Player.Sigma}
+	}
+	return &mmrCustom.Team{Players: players}
+}
+
+func fromCustomTeam(team TeamV2, custom *mmrCustom.Team) TeamV2 {
+	ratings := make(types.Team, len(custom.Players))
+	for i, p := range custom.Players {
+		ratings[i] = types.Rating{Mu: p.MMR, Sigma: p.Uncertainty}
+	}
+	return team.withRatings(ratings)
+}
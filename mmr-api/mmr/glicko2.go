@@ -0,0 +1,117 @@
+package mmr
+
+import (
+	"fmt"
+
+	"github.com/intinig/go-openskill/types"
+	"mmr/backend/glicko2"
+)
+
+// glicko2Algorithm rates team matches with Glicko-2 by treating every match as
+// its own rating period. Each player is rated against a composite of every
+// opposing team. Composites average their players, so in uneven matches the
+// bigger team gets no credit for its extra players. The Glicko rating travels
+// in Mu and the rating deviation in Sigma; volatility has its own field on
+// PlayerV2.
+type glicko2Algorithm struct {
+	// newRating overrides the default starting rating when set by a Profile.
+	newRating startingRating
+	// tau overrides glicko2.DefaultTau when set by a Profile.
+	tau *float64
+}
+
+func (glicko2Algorithm) Name() string {
+	return AlgorithmGlicko2
+}
+
+func (a glicko2Algorithm) NewRating() types.Rating {
+	return a.newRating.or(types.Rating{Mu: glicko2.DefaultRating, Sigma: glicko2.DefaultRD})
+}
+
+func (a glicko2Algorithm) withProfile(profile Profile) RatingAlgorithm {
+	a.newRating = profile.startingRating()
+	a.tau = profile.Tau
+	return a
+}
+
+func (glicko2Algorithm) DisplayValue(rating types.Rating) float64 {
+	return rating.Mu
+}
+
+func (a glicko2Algorithm) Rate(teams []TeamV2) ([]TeamV2, error) {
+	tau := float64(glicko2.DefaultTau)
+	if a.tau != nil {
+		// A tau of 0 suits the OpenSkill algorithms, but would freeze
+		// Glicko-2 volatility entirely
+		if !(*a.tau > 0) {
+			return nil, fmt.Errorf("%s needs a profile tau greater than 0", AlgorithmGlicko2)
+		}
+		tau = *a.tau
+	}
+
+	// Grow the deviation of players returning from a break before the match
+	// so the result counts for more.
+	current := make([][]glicko2.Player, len(teams))
+	composites := make([]glicko2.Player, len(teams))
+	for i, team := range teams {
+		current[i] = make([]glicko2.Player, len(team.Players))
+		for j, p := range team.Players {
+			current[i][j] = toGlicko2Player(p).Idle(p.InactivePeriods)
+		}
+		composites[i] = glicko2.Composite(current[i])
+	}
+
+	rated := make([]TeamV2, len(teams))
+	for i, team := range teams {
+		results := make([]glicko2.Result, 0, len(teams)-1)
+		for q, opponent := range teams {
+			if q == i {
+				continue
+			}
+			results = append(results, glicko2.Result{Opponent: composites[q], Score: matchScore(team.Score, opponent.Score)})
+		}
+
+		players := make([]PlayerV2, len(team.Players))
+		for j, p := range team.Players {
+			updated := current[i][j].UpdateWithTau(results, tau)
+			players[j] = p
+			players[j].Player = types.Rating{Mu: updated.Rating, Sigma: updated.RD}
+			players[j].Volatility = updated.Volatility
+		}
+		rated[i] = TeamV2{Players: players, Score: team.Score}
+	}
+	return rated, nil
+}
+
+func (glicko2Algorithm) expectedScore(team, opponent TeamV2) float64 {
+	return idleComposite(team).ExpectedScore(idleComposite(opponent))
+}
+
+// idleComposite is the team composite Rate rates against, after inactivity.
+func idleComposite(team TeamV2) glicko2.Player {
+	players := make([]glicko2.Player, len(team.Players))
+	for i, p := range team.Players {
+		players[i] = toGlicko2Player(p).Idle(p.InactivePeriods)
+	}
+	return glicko2.Composite(players)
+}
+
+func toGlicko2Player(p PlayerV2) glicko2.Player {
+	volatility := p.Volatility
+	if volatility <= 0 {
+		volatility = glicko2.DefaultVolatility
+	}
+	return glicko2.Player{Rating: p.Player.Mu, RD: p.Player.Sigma, Volatility: volatility}
+}
+
+// matchScore is 1 if score beat opponentScore, 0 if it lost and 0.5 for a draw.
+func matchScore(score, opponentScore int16) float64 {
+	switch {
+	case score > opponentScore:
+		return 1
+	case score < opponentScore:
+		return 0
+	default:
+		return 0.5
+	}
+}
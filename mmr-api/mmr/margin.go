@@ -0,0 +1,92 @@
+package mmr
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	MarginCurveLinear = "linear"
+	MarginCurveLog    = "log"
+	MarginCurveSqrt   = "sqrt"
+
+	DefaultMarginCurve = MarginCurveLog
+	DefaultMarginScale = 0.5
+	DefaultMarginCap   = 2.0
+)
+
+// MarginModel scales mu changes by the winning margin so a 10-0 blowout moves
+// ratings further than a 10-9 win. A one-point win (or a draw) always has a
+// multiplier of 1; larger margins grow along Curve at Scale, up to Cap.
+type MarginModel struct {
+	Curve string
+	Scale float64
+	Cap   float64
+}
+
+// NewMarginModel returns a model with defaults filled in for any empty curve
+// or nil scale and cap.
+func NewMarginModel(curve string, scale *float64, cap *float64) (MarginModel, error) {
+	model := MarginModel{Curve: curve, Scale: DefaultMarginScale, Cap: DefaultMarginCap}
+	if model.Curve == "" {
+		model.Curve = DefaultMarginCurve
+	}
+	if scale != nil {
+		model.Scale = *scale
+	}
+	if cap != nil {
+		model.Cap = *cap
+	}
+
+	switch model.Curve {
+	case MarginCurveLinear, MarginCurveLog, MarginCurveSqrt:
+	default:
+		return MarginModel{}, fmt.Errorf("unknown margin curve %q, expected %s, %s or %s", model.Curve, MarginCurveLinear, MarginCurveLog, MarginCurveSqrt)
+	}
+	if !(model.Scale >= 0) {
+		return MarginModel{}, fmt.Errorf("margin scale must not be negative")
+	}
+	if !(model.Cap >= 1) {
+		return MarginModel{}, fmt.Errorf("margin cap must be at least 1")
+	}
+	return model, nil
+}
+
+// Multiplier is the factor mu changes are scaled by for a match won by margin
+// points.
+func (m MarginModel) Multiplier(margin int) float64 {
+	if margin < 0 {
+		margin = -margin
+	}
+	if margin <= 1 {
+		return 1
+	}
+
+	var growth float64
+	switch m.Curve {
+	case MarginCurveLinear:
+		growth = float64(margin - 1)
+	case MarginCurveSqrt:
+		growth = math.Sqrt(float64(margin)) - 1
+	default:
+		growth = math.Log(float64(margin))
+	}
+	return math.Min(1+m.Scale*growth, m.Cap)
+}
+
+// MatchMultiplier is the Multiplier for a match, using the spread between the
+// best and worst score. For two teams that is the winning margin.
+func (m MarginModel) MatchMultiplier(teams []TeamV2) float64 {
+	best, worst := teams[0].Score, teams[0].Score
+	for _, team := range teams[1:] {
+		best = max(best, team.Score)
+		worst = min(worst, team.Score)
+	}
+	return m.Multiplier(int(best) - int(worst))
+}
+
+// marginAware is implemented by algorithms that already account for the
+// score margin themselves, where a MarginModel would count it twice.
+type marginAware interface {
+	accountsForMargin()
+}
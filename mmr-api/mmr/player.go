@@ -8,6 +8,24 @@ type Player struct {
 }
 
 type PlayerV2 struct {
-	Id int64
-	Player   types.Rating
+	Id     int64
+	Player types.Rating
+	// Weight is the share of the match the player took part in, in (0, 1].
+	// Zero means the player played the whole match.
+	Weight float64
+	// Volatility and InactivePeriods are only used by Glicko-2; other
+	// algorithms leave them untouched.
+	Volatility      float64
+	InactivePeriods int
+	// Tier is the player's competitive tier, see TierLadder. Rating
+	// algorithms leave it untouched, so after rating it is the previous tier.
+	Tier string
+}
+
+// weight returns the player's Weight, treating zero as the whole match.
+func (p PlayerV2) weight() float64 {
+	if p.Weight == 0 {
+		return 1
+	}
+	return p.Weight
 }
@@ -0,0 +1,137 @@
+package mmr
+
+import (
+	"math"
+)
+
+// The predictions below follow OpenSkill's predict_win and predict_draw and
+// TrueSkill's match quality. They work on the mu/sigma scale shared by the
+// Weng-Lin algorithms and don't change any ratings.
+
+// PredictWin returns each team's probability of beating the others, in team
+// order, with the default beta. The probabilities sum to 1; draws are not
+// split out.
+func PredictWin(teams []TeamV2) []float64 {
+	return predictWin(teams, wengLinBeta)
+}
+
+func predictWin(teams []TeamV2, beta float64) []float64 {
+	stats := predictionStats(teams)
+	n := float64(len(teams))
+	return pairwiseWinProbabilities(len(teams), func(i, j int) float64 {
+		return normalCDF((stats[i].mu - stats[j].mu) / math.Sqrt(n*beta*beta+stats[i].sigmaSq+stats[j].sigmaSq))
+	})
+}
+
+// betaScaled is implemented by algorithms on the OpenSkill scale, whose beta
+// a Profile may change.
+type betaScaled interface {
+	betaOrDefault() float64
+}
+
+// winPredictor is implemented by algorithms whose ratings aren't on the
+// OpenSkill scale. expectedScore is the chance team beats opponent.
+type winPredictor interface {
+	expectedScore(team, opponent TeamV2) float64
+}
+
+// PredictWinFor is PredictWin with the algorithm's beta, so the odds match
+// how the teams are rated, or the algorithm's own expected scores when its
+// ratings aren't on the OpenSkill scale.
+func PredictWinFor(algorithm RatingAlgorithm, teams []TeamV2) []float64 {
+	predictor, ok := algorithm.(winPredictor)
+	if !ok {
+		if scaled, ok := algorithm.(betaScaled); ok {
+			return predictWin(teams, scaled.betaOrDefault())
+		}
+		return PredictWin(teams)
+	}
+	return pairwiseWinProbabilities(len(teams), func(i, j int) float64 {
+		return predictor.expectedScore(teams[i], teams[j])
+	})
+}
+
+// pairwiseWinProbabilities sums every team's expected score against each
+// opponent and normalises the sums so they add up to 1.
+// Glicko-2 expected scores of a pair don't quite add up to 1, so this divides
+// by the total rather than the number of pairs.
+func pairwiseWinProbabilities(n int, expected func(i, j int) float64) []float64 {
+	probabilities := make([]float64, n)
+	var total float64
+	for i := range probabilities {
+		for j := 0; j < n; j++ {
+			if i != j {
+				probabilities[i] += expected(i, j)
+			}
+		}
+		total += probabilities[i]
+	}
+	for i := range probabilities {
+		probabilities[i] /= total
+	}
+	return probabilities
+}
+
+// PredictDraw returns the probability that the match ends in a draw, averaged
+// over every pair of teams. The draw margin assumes a base draw rate of one
+// over the number of players. Unlike some OpenSkill ports, the performance
+// difference is measured against both edges of the margin, so a lopsided
+// match can't produce a negative probability.
+func PredictDraw(teams []TeamV2) float64 {
+	stats := predictionStats(teams)
+	playerCount := 0
+	for _, team := range teams {
+		playerCount += len(team.Players)
+	}
+	n := float64(len(teams))
+
+	drawProbability := 1 / float64(playerCount)
+	drawMargin := math.Sqrt(float64(playerCount)) * wengLinBeta * normalInverseCDF((1+drawProbability)/2)
+
+	var total float64
+	var pairs int
+	for i, team := range stats {
+		for j := i + 1; j < len(stats); j++ {
+			opponent := stats[j]
+			denominator := math.Sqrt(n*wengLinBeta*wengLinBeta + team.sigmaSq + opponent.sigmaSq)
+			muDiff := team.mu - opponent.mu
+			total += normalCDF((drawMargin-muDiff)/denominator) - normalCDF((-drawMargin-muDiff)/denominator)
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+// MatchQuality returns how evenly matched the teams are, from 0 to 1, as the
+// average TrueSkill match quality over every pair of teams. Higher is more
+// balanced; uncertain ratings lower it.
+func MatchQuality(teams []TeamV2) float64 {
+	stats := predictionStats(teams)
+
+	var total float64
+	var pairs int
+	for i, team := range stats {
+		for j := i + 1; j < len(stats); j++ {
+			opponent := stats[j]
+			playerCount := float64(len(teams[i].Players) + len(teams[j].Players))
+			betaSq := playerCount * wengLinBeta * wengLinBeta
+			variance := betaSq + team.sigmaSq + opponent.sigmaSq
+			muDiff := team.mu - opponent.mu
+			total += math.Sqrt(betaSq/variance) * math.Exp(-muDiff*muDiff/(2*variance))
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+func predictionStats(teams []TeamV2) []teamStats {
+	stats := make([]teamStats, len(teams))
+	for i, team := range teams {
+		stats[i] = newTeamStats(team)
+	}
+	return stats
+}
+
+func normalInverseCDF(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
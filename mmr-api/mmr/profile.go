@@ -0,0 +1,220 @@
+package mmr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/intinig/go-openskill/types"
+	view "mmr/backend/models"
+)
+
+// Profile is a named set of rating defaults, so each league can tune its own
+// without a redeploy. Nil values keep the built-in defaults. Beta and
+// DisplayMultiplier only apply to the OpenSkill scale algorithms. Tau is the
+// dynamics factor there and the system constant for Glicko-2; Elo takes just
+// the starting Mu and Sigma.
+type Profile struct {
+	Name              string
+	Algorithm         string // Used when a request doesn't name one
+	Mu                *float64
+	Sigma             *float64
+	Beta              *float64
+	Tau               *float64
+	DisplayMultiplier *float64
+	CarryOver         *CarryOverPolicy // Used when a request doesn't set one
+	Display           *DisplayMapping  // Used when a request doesn't set one
+	TierLadder        *TierLadder      // Used when a request doesn't set one
+}
+
+// profileConfig is a profile as written in the profiles file.
+type profileConfig struct {
+	Algorithm         string                   `json:"algorithm"`
+	Mu                *float64                 `json:"mu"`
+	Sigma             *float64                 `json:"sigma"`
+	Beta              *float64                 `json:"beta"`
+	Tau               *float64                 `json:"tau"`
+	DisplayMultiplier *float64                 `json:"displayMultiplier"`
+	CarryOver         *view.MMRCarryOverPolicy `json:"carryOver"`
+	Display           *view.MMRDisplayMapping  `json:"display"`
+	TierLadder        *view.MMRTierLadder      `json:"tierLadder"`
+}
+
+// profiled algorithms can be tuned by a Profile.
+type profiled interface {
+	withProfile(profile Profile) RatingAlgorithm
+}
+
+// profiles is only written by LoadProfiles at startup, before requests are
+// served.
+var profiles = map[string]Profile{}
+
+// LoadProfiles replaces the registered profiles with those in the JSON file
+// at path, an object mapping each profile name to its settings.
+func LoadProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading rating profiles: %w", err)
+	}
+	loaded, err := ParseProfiles(data)
+	if err != nil {
+		return err
+	}
+	profiles = loaded
+	return nil
+}
+
+// ParseProfiles parses and validates the contents of a profiles file.
+// Unknown settings are rejected so a typo doesn't silently use a default.
+func ParseProfiles(data []byte) (map[string]Profile, error) {
+	var configs map[string]profileConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&configs); err != nil {
+		return nil, fmt.Errorf("parsing rating profiles: %w", err)
+	}
+
+	parsed := make(map[string]Profile, len(configs))
+	for name, config := range configs {
+		profile, err := newProfile(name, config)
+		if err != nil {
+			return nil, fmt.Errorf("rating profile %q: %w", name, err)
+		}
+		parsed[name] = profile
+	}
+	return parsed, nil
+}
+
+func newProfile(name string, config profileConfig) (Profile, error) {
+	profile := Profile{Name: name, Algorithm: config.Algorithm}
+	if config.Algorithm != "" {
+		if _, err := AlgorithmByName(config.Algorithm); err != nil {
+			return Profile{}, err
+		}
+	}
+
+	if config.Mu != nil {
+		if math.IsNaN(*config.Mu) || math.IsInf(*config.Mu, 0) {
+			return Profile{}, fmt.Errorf("mu must be a finite number")
+		}
+		profile.Mu = config.Mu
+	}
+	// Written so NaN fails too
+	if config.Sigma != nil {
+		if !(*config.Sigma > 0) {
+			return Profile{}, fmt.Errorf("sigma must be greater than 0")
+		}
+		profile.Sigma = config.Sigma
+	}
+	if config.Beta != nil {
+		if !(*config.Beta > 0) {
+			return Profile{}, fmt.Errorf("beta must be greater than 0")
+		}
+		profile.Beta = config.Beta
+	}
+	if config.Tau != nil {
+		if !(*config.Tau >= 0) {
+			return Profile{}, fmt.Errorf("tau must not be negative")
+		}
+		if config.Algorithm == AlgorithmGlicko2 && *config.Tau == 0 {
+			return Profile{}, fmt.Errorf("tau must be greater than 0 for %s", AlgorithmGlicko2)
+		}
+		profile.Tau = config.Tau
+	}
+	if config.DisplayMultiplier != nil {
+		if !(*config.DisplayMultiplier > 0) {
+			return Profile{}, fmt.Errorf("displayMultiplier must be greater than 0")
+		}
+		profile.DisplayMultiplier = config.DisplayMultiplier
+	}
+	if config.CarryOver != nil {
+		carryOver, err := NewCarryOverPolicy(config.CarryOver.Policy, config.CarryOver.Fraction, config.CarryOver.SigmaInflation, config.CarryOver.LeagueMean)
+		if err != nil {
+			return Profile{}, err
+		}
+		profile.CarryOver = &carryOver
+	}
+	if config.Display != nil {
+		display, err := NewDisplayMapping(*config.Display)
+		if err != nil {
+			return Profile{}, err
+		}
+		profile.Display = &display
+	}
+	if config.TierLadder != nil {
+		ladder, err := NewTierLadder(*config.TierLadder)
+		if err != nil {
+			return Profile{}, err
+		}
+		profile.TierLadder = &ladder
+	}
+	return profile, nil
+}
+
+// ProfileByName looks up a loaded profile. An empty name selects the built-in
+// defaults.
+func ProfileByName(name string) (Profile, error) {
+	if name == "" {
+		return Profile{}, nil
+	}
+	profile, exists := profiles[name]
+	if !exists {
+		if len(profiles) == 0 {
+			return Profile{}, fmt.Errorf("unknown profile %q, no profiles are configured", name)
+		}
+		return Profile{}, fmt.Errorf("unknown profile %q, expected one of: %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// ProfileNames returns the names of all loaded profiles, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RatingAlgorithm looks up the named algorithm, or the profile's algorithm
+// when name is empty, and tunes it with the profile.
+func (p Profile) RatingAlgorithm(name string) (RatingAlgorithm, error) {
+	if name == "" {
+		name = p.Algorithm
+	}
+	algorithm, err := AlgorithmByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if tunable, ok := algorithm.(profiled); ok {
+		algorithm = tunable.withProfile(p)
+	}
+	return algorithm, nil
+}
+
+// startingRating is a profile's starting rating. Nil fields keep the
+// algorithm's default.
+type startingRating struct {
+	mu    *float64
+	sigma *float64
+}
+
+func (p Profile) startingRating() startingRating {
+	return startingRating{mu: p.Mu, sigma: p.Sigma}
+}
+
+// or fills the fields the profile doesn't set from defaultRating.
+func (r startingRating) or(defaultRating types.Rating) types.Rating {
+	if r.mu != nil {
+		defaultRating.Mu = *r.mu
+	}
+	if r.sigma != nil {
+		defaultRating.Sigma = *r.sigma
+	}
+	return defaultRating
+}
@@ -1,5 +1,7 @@
 package mmr
 
+import "github.com/intinig/go-openskill/types"
+
 // Team is a composition of players that play together. The skill of a team
 // (µ and σ) is determined by the skills of the players that form the team.
 type Team struct {
@@ -12,6 +14,35 @@ type TeamV2 struct {
 	Score   int16
 }
 
+// ratings returns the ratings of the team's players in player order.
+func (t TeamV2) ratings() types.Team {
+	ratings := make(types.Team, len(t.Players))
+	for i, p := range t.Players {
+		ratings[i] = p.Player
+	}
+	return ratings
+}
+
+// weights returns the weights of the team's players in player order.
+func (t TeamV2) weights() []float64 {
+	weights := make([]float64, len(t.Players))
+	for i, p := range t.Players {
+		weights[i] = p.weight()
+	}
+	return weights
+}
+
+// withRatings returns a copy of the team with the players' ratings replaced,
+// leaving the receiver untouched.
+func (t TeamV2) withRatings(ratings types.Team) TeamV2 {
+	players := make([]PlayerV2, len(t.Players))
+	for i, p := range t.Players {
+		players[i] = p
+		players[i].Player = ratings[i]
+	}
+	return TeamV2{Players: players, Score: t.Score}
+}
+
 // Size returns the number of players in the team
 func (t *Team) Size() int {
 	return len(t.Players)
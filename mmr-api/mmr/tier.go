@@ -0,0 +1,96 @@
+package mmr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	view "mmr/backend/models"
+)
+
+// MaxTierDivisions bounds the divisions per tier so names stay readable.
+const MaxTierDivisions = 5
+
+var romanNumerals = []string{"I", "II", "III", "IV", "V"}
+
+// TierLadder assigns competitive tiers like "Gold II" from a player's MMR.
+// Every tier but the top one is split into equal divisions, numbered from the
+// highest (I) down. A player only leaves their previous division once their
+// MMR is more than Hysteresis past its edges, so they don't flip between
+// divisions every match.
+type TierLadder struct {
+	Hysteresis float64
+	divisions  []division // Lowest first
+}
+
+type division struct {
+	name     string
+	min, max float64
+}
+
+// NewTierLadder validates config and builds its divisions. Tier thresholds
+// are in the same units as the display value returned for players, or their
+// MMR when there is no display mapping.
+func NewTierLadder(config view.MMRTierLadder) (TierLadder, error) {
+	if len(config.Tiers) == 0 {
+		return TierLadder{}, fmt.Errorf("a tier ladder needs at least one tier")
+	}
+	divisions := config.Divisions
+	if divisions == 0 {
+		divisions = 1
+	}
+	if divisions < 1 || divisions > MaxTierDivisions {
+		return TierLadder{}, fmt.Errorf("tiers can have between 1 and %d divisions", MaxTierDivisions)
+	}
+	// Written so NaN fails too
+	if !(config.Hysteresis >= 0) {
+		return TierLadder{}, fmt.Errorf("tier hysteresis must not be negative")
+	}
+
+	ladder := TierLadder{Hysteresis: config.Hysteresis}
+	for i, tier := range config.Tiers {
+		if tier.Name == "" {
+			return TierLadder{}, fmt.Errorf("tier %d has no name", i)
+		}
+		if i == len(config.Tiers)-1 {
+			ladder.divisions = append(ladder.divisions, division{name: tier.Name, min: tier.Min, max: math.Inf(1)})
+			break
+		}
+
+		next := config.Tiers[i+1].Min
+		if !(next > tier.Min) {
+			return TierLadder{}, fmt.Errorf("tiers must be ordered by increasing min")
+		}
+		width := (next - tier.Min) / float64(divisions)
+		for d := 0; d < divisions; d++ {
+			name := tier.Name
+			if divisions > 1 {
+				name += " " + romanNumerals[divisions-1-d]
+			}
+			ladder.divisions = append(ladder.divisions, division{name: name, min: tier.Min + float64(d)*width, max: tier.Min + float64(d+1)*width})
+		}
+	}
+	// Players below the lowest tier still belong to it
+	ladder.divisions[0].min = math.Inf(-1)
+	return ladder, nil
+}
+
+// Assign returns the tier for mmr. previous is the player's tier before the
+// match; it may be empty, and a tier that isn't on the ladder is ignored.
+func (l TierLadder) Assign(mmr float64, previous string) string {
+	for _, d := range l.divisions {
+		if strings.EqualFold(d.name, previous) {
+			if mmr >= d.min-l.Hysteresis && mmr < d.max+l.Hysteresis {
+				return d.name
+			}
+			break
+		}
+	}
+
+	for i := len(l.divisions) - 1; i > 0; i-- {
+		if mmr >= l.divisions[i].min {
+			return l.divisions[i].name
+		}
+	}
+	return l.divisions[0].name
+}
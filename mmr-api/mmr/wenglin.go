@@ -0,0 +1,221 @@
+package mmr
+
+import (
+	"math"
+	"sort"
+
+	"github.com/intinig/go-openskill/types"
+)
+
+// The Bradley-Terry and Thurstone-Mosteller models below are the Weng-Lin
+// update rules OpenSkill describes alongside Plackett-Luce. They use the same
+// beta and epsilon as OpenSkill's defaults so every algorithm rates on the
+// same mu/sigma scale and players can move between them.
+const (
+	wengLinBeta    = 25.0 / 6
+	wengLinEpsilon = 0.0001
+)
+
+// teamStats is the aggregate rating of a team as the Weng-Lin models see it.
+// Like OpenSkill, every player counts towards it in proportion to their
+// weight.
+type teamStats struct {
+	mu      float64
+	sigmaSq float64
+	score   int16
+}
+
+func newTeamStats(team TeamV2) teamStats {
+	stats := teamStats{score: team.Score}
+	for _, p := range team.Players {
+		stats.mu += p.weight() * p.Player.Mu
+		stats.sigmaSq += p.weight() * p.Player.Sigma * p.Player.Sigma
+	}
+	return stats
+}
+
+// outcome is 1 if team beat opponent, 0 if it lost and 0.5 for a draw.
+func (team teamStats) outcome(opponent teamStats) float64 {
+	return matchScore(team.score, opponent.score)
+}
+
+// wengLinAlgorithm compares every team against the opponents chosen by pairs
+// and sums the per-opponent omega (mu) and delta (sigma) updates.
+type wengLinAlgorithm struct {
+	openSkillScale
+
+	name string
+	// pairs returns the positions, in finishing order, that the team at
+	// position i is compared against.
+	pairs  func(i, n int) []int
+	update func(team, opponent teamStats, beta float64) (omega, delta float64)
+}
+
+func (a wengLinAlgorithm) Name() string {
+	return a.name
+}
+
+func (a wengLinAlgorithm) withProfile(profile Profile) RatingAlgorithm {
+	a.openSkillScale = newOpenSkillScale(profile)
+	return a
+}
+
+// The team sums and every player's share of the update are weighted.
+func (wengLinAlgorithm) accountsForWeight() {}
+
+func (a wengLinAlgorithm) Rate(teams []TeamV2) ([]TeamV2, error) {
+	teams = a.addDynamics(teams)
+	stats := make([]teamStats, len(teams))
+	for i, team := range teams {
+		stats[i] = newTeamStats(team)
+	}
+
+	order := make([]int, len(teams))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return stats[order[x]].score > stats[order[y]].score
+	})
+
+	rated := make([]TeamV2, len(teams))
+	for pos, i := range order {
+		var omega, delta float64
+		for _, opponentPos := range a.pairs(pos, len(order)) {
+			o, d := a.update(stats[i], stats[order[opponentPos]], a.betaOrDefault())
+			omega += o
+			delta += d
+		}
+		rated[i] = applyWengLinUpdate(teams[i], stats[i].sigmaSq, omega, delta)
+	}
+	return rated, nil
+}
+
+// applyWengLinUpdate spreads a team-level update over its players in
+// proportion to each player's share of the team variance, scaled by their
+// weight.
+func applyWengLinUpdate(team TeamV2, teamSigmaSq, omega, delta float64) TeamV2 {
+	ratings := make(types.Team, len(team.Players))
+	for i, p := range team.Players {
+		sigmaSq := p.Player.Sigma * p.Player.Sigma
+		share := p.weight() * sigmaSq / teamSigmaSq
+		ratings[i] = types.Rating{
+			Mu:    p.Player.Mu + share*omega,
+			Sigma: p.Player.Sigma * math.Sqrt(math.Max(1-share*delta, wengLinEpsilon)),
+		}
+	}
+	return team.withRatings(ratings)
+}
+
+func allPairs(i, n int) []int {
+	pairs := make([]int, 0, n-1)
+	for q := 0; q < n; q++ {
+		if q != i {
+			pairs = append(pairs, q)
+		}
+	}
+	return pairs
+}
+
+func adjacentPairs(i, n int) []int {
+	pairs := make([]int, 0, 2)
+	if i > 0 {
+		pairs = append(pairs, i-1)
+	}
+	if i < n-1 {
+		pairs = append(pairs, i+1)
+	}
+	return pairs
+}
+
+func pairwiseC(team, opponent teamStats, beta float64) float64 {
+	return math.Sqrt(team.sigmaSq + opponent.sigmaSq + 2*beta*beta)
+}
+
+func bradleyTerryUpdate(team, opponent teamStats, beta float64) (float64, float64) {
+	c := pairwiseC(team, opponent, beta)
+	p := 1 / (1 + math.Exp((opponent.mu-team.mu)/c))
+	sigmaSqToC := team.sigmaSq / c
+	gamma := math.Sqrt(team.sigmaSq) / c
+
+	omega := sigmaSqToC * (team.outcome(opponent) - p)
+	delta := gamma * sigmaSqToC / c * p * (1 - p)
+	return omega, delta
+}
+
+func thurstoneMostellerUpdate(team, opponent teamStats, beta float64) (float64, float64) {
+	c := pairwiseC(team, opponent, beta)
+	deltaMu := (team.mu - opponent.mu) / c
+	sigmaSqToC := team.sigmaSq / c
+	gamma := math.Sqrt(team.sigmaSq) / c
+	drawMargin := wengLinEpsilon / c
+	deltaScale := gamma * team.sigmaSq / (c * c)
+
+	if team.score == opponent.score {
+		return sigmaSqToC * vt(deltaMu, drawMargin), deltaScale * wt(deltaMu, drawMargin)
+	}
+
+	sign := 1.0
+	if team.score < opponent.score {
+		sign = -1
+	}
+	return sign * sigmaSqToC * v(sign*deltaMu, drawMargin), deltaScale * w(sign*deltaMu, drawMargin)
+}
+
+// v, w, vt and wt are the truncated Gaussian corrections from TrueSkill used
+// by the Thurstone-Mosteller model for decisive (v, w) and drawn (vt, wt)
+// outcomes.
+
+func v(x, t float64) float64 {
+	xt := x - t
+	denom := normalCDF(xt)
+	if denom < wengLinEpsilon {
+		return -xt
+	}
+	return normalPDF(xt) / denom
+}
+
+func w(x, t float64) float64 {
+	xt := x - t
+	denom := normalCDF(xt)
+	if denom < wengLinEpsilon {
+		if x < 0 {
+			return 1
+		}
+		return 0
+	}
+	return v(x, t) * (v(x, t) + xt)
+}
+
+func vt(x, t float64) float64 {
+	xx := math.Abs(x)
+	b := normalCDF(t-xx) - normalCDF(-t-xx)
+	if b < 1e-5 {
+		if x < 0 {
+			return -x - t
+		}
+		return -x + t
+	}
+	a := normalPDF(-t-xx) - normalPDF(t-xx)
+	if x < 0 {
+		return -a / b
+	}
+	return a / b
+}
+
+func wt(x, t float64) float64 {
+	xx := math.Abs(x)
+	b := normalCDF(t-xx) - normalCDF(-t-xx)
+	if b < wengLinEpsilon {
+		return 1
+	}
+	return ((t-xx)*normalPDF(t-xx)+(t+xx)*normalPDF(-t-xx))/b + vt(x, t)*vt(x, t)
+}
+
+func normalPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func normalCDF(x float64) float64 {
+	return math.Erfc(-x/math.Sqrt2) / 2
+}
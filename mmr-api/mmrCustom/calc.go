@@ -10,6 +10,22 @@ type MatchOutcome int
 const (
 	Team1Wins MatchOutcome = iota
 	Team2Wins
+	Draw
+)
+
+const (
+	// DefaultMMR is the rating of a player who hasn't played yet.
+	DefaultMMR = 1500
+	// DefaultUncertainty is the uncertainty of a player who hasn't played yet.
+	DefaultUncertainty = 200
+	// MinUncertainty is the floor uncertainty decays towards.
+	MinUncertainty = 50
+	// UncertaintyDecay is the factor a player's uncertainty is multiplied by
+	// after each game, so it reaches MinUncertainty after roughly 30 games.
+	UncertaintyDecay = 0.955
+	// K is the adjustment factor for a player at DefaultUncertainty. A player
+	// at MinUncertainty moves a quarter as much (40 down to 10, as in FIDE).
+	K = 40
 )
 
 // CalculateTeamMMR calculates the MMR of a team by summing the MMR of its players.
@@ -21,35 +37,101 @@ func CalculateTeamMMR(team *Team) float64 {
 	return totalMMR
 }
 
-// UpdateMMR updates the MMR of each player based on the match outcome and the predicted outcome.
+// CalculateAverageTeamMMR calculates the MMR of a team as the mean MMR of
+// its players, so teams of different sizes can be compared.
+func CalculateAverageTeamMMR(team *Team) float64 {
+	if len(team.Players) == 0 {
+		return 0
+	}
+	return CalculateTeamMMR(team) / float64(len(team.Players))
+}
+
+// ExpectedScore is the score a team rated teamMMR is expected to get against
+// one rated opponentMMR: 1 for a certain win, 0.5 for even teams.
+func ExpectedScore(teamMMR, opponentMMR float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponentMMR-teamMMR)/400))
+}
+
+// UpdateMMR updates the MMR and uncertainty of each player based on the
+// match outcome and the predicted outcome, without regard to the margin.
 func UpdateMMR(team1, team2 *Team, outcome MatchOutcome) {
-	team1MMR := CalculateTeamMMR(team1)
-	team2MMR := CalculateTeamMMR(team2)
+	UpdateMMRWithMargin(team1, team2, outcome, 0)
+}
 
-	// Calculate predicted outcome based on MMR difference
-	team1Expected := 1 / (1 + math.Pow(10, (team2MMR-team1MMR)/400))
-	team2Expected := 1 / (1 + math.Pow(10, (team1MMR-team2MMR)/400))
+// UpdateMMRWithMargin updates the MMR and uncertainty of each player based on
+// the match outcome and the predicted outcome. Teams are compared by their
+// mean MMR, so uneven teams can be rated. margin is the absolute score
+// difference; wins by more than one point move ratings further.
+func UpdateMMRWithMargin(team1, team2 *Team, outcome MatchOutcome, margin int) {
+	team1MMR := CalculateAverageTeamMMR(team1)
+	team2MMR := CalculateAverageTeamMMR(team2)
 
-	const K = 32 // Adjustment factor for MMR update
+	// Calculate predicted outcome based on MMR difference
+	team1Expected := ExpectedScore(team1MMR, team2MMR)
+	team2Expected := ExpectedScore(team2MMR, team1MMR)
 
 	// Update player MMRs based on outcome
-	var team1Result, team2Result float64
+	team1Result, team2Result := results(outcome)
+
+	multiplier := MarginMultiplier(margin)
+	if outcome == Draw {
+		multiplier = 1
+	}
+
+	for _, player := range team1.Players {
+		player.update(multiplier * (team1Result - team1Expected))
+	}
+
+	for _, player := range team2.Players {
+		player.update(multiplier * (team2Result - team2Expected))
+	}
+}
+
+// results returns each team's score for outcome.
+func results(outcome MatchOutcome) (float64, float64) {
 	switch outcome {
 	case Team1Wins:
-		team1Result = 1.0
-		team2Result = 0.0
+		return 1.0, 0.0
 	case Team2Wins:
-		team1Result = 0.0
-		team2Result = 1.0
+		return 0.0, 1.0
+	default:
+		return 0.5, 0.5
 	}
+}
 
-	// TODO: Update player uncertainty
-
-	for _, player := range team1.Players {
-		player.MMR += K * (team1Result - team1Expected)
+// MarginMultiplier scales the update by the winning margin using the World
+// Football Elo index: 1 for a one-point win, 1.5 for two points and
+// (11+N)/8 for N >= 3.
+func MarginMultiplier(margin int) float64 {
+	if margin < 0 {
+		margin = -margin
 	}
+	switch {
+	case margin <= 1:
+		return 1
+	case margin == 2:
+		return 1.5
+	default:
+		return (11 + float64(margin)) / 8
+	}
+}
 
-	for _, player := range team2.Players {
-		player.MMR += K * (team2Result - team2Expected)
+// EffectiveK is the adjustment factor for a player with the given
+// uncertainty. It scales linearly so uncertain players converge quickly and
+// established players are stable.
+func EffectiveK(uncertainty float64) float64 {
+	return K * clampUncertainty(uncertainty) / DefaultUncertainty
+}
+
+func (p *Player) update(scaledSurprise float64) {
+	if p.Uncertainty <= 0 {
+		p.Uncertainty = DefaultUncertainty
 	}
+
+	p.MMR += EffectiveK(p.Uncertainty) * scaledSurprise
+	p.Uncertainty = math.Max(clampUncertainty(p.Uncertainty)*UncertaintyDecay, MinUncertainty)
+}
+
+func clampUncertainty(uncertainty float64) float64 {
+	return math.Min(math.Max(uncertainty, MinUncertainty), DefaultUncertainty)
 }
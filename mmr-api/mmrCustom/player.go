@@ -1,6 +1,7 @@
 package mmrCustom
 
-// Player represents a player with their MMR and uncertainty.
+// Player represents a player with their MMR and uncertainty. A zero
+// Uncertainty is treated as DefaultUncertainty.
 type Player struct {
 	Initials    string
 	MMR         float64
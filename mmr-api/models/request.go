@@ -3,6 +3,37 @@ package view
 type MMRCalculationRequest struct {
 	Team1 MMRCalculationTeam `json:"team1" binding:"required"`
 	Team2 MMRCalculationTeam `json:"team2" binding:"required"`
+	MMRCalculationOptions
+}
+
+// MMRCalculationOptions are how a match is rated, shared by both request
+// shapes.
+type MMRCalculationOptions struct {
+	// Algorithm is plackett-luce, bradley-terry-full, bradley-terry-part,
+	// thurstone-mosteller-full, thurstone-mosteller-part, elo or glicko2;
+	// empty selects the profile's or the default
+	Algorithm string `json:"algorithm,omitempty"`
+	// Profile names a server-side rating profile whose algorithm, starting
+	// rating, beta, tau, display multiplier, carry-over policy and display
+	// mapping apply where the request doesn't set its own
+	Profile string `json:"profile,omitempty"`
+	// RequireEqualTeamSizes rejects matches like 2v1 instead of rating them
+	RequireEqualTeamSizes bool `json:"requireEqualTeamSizes,omitempty"`
+	// Outcome is empty to read the result from scores or ranks, or "draw" to
+	// rate the match as a draw whatever the scores
+	Outcome string `json:"outcome,omitempty"`
+	// DrawMargin counts teams whose scores are within this many points as a draw
+	DrawMargin int `json:"drawMargin,omitempty"`
+	// MarginModel scales rating changes by the winning margin when set
+	MarginModel *MMRMarginModel `json:"marginModel,omitempty"`
+	// CarryOver sets how players flagged with isPreviousSeasonRating start the
+	// new season; echoed in the response
+	CarryOver *MMRCarryOverPolicy `json:"carryOver,omitempty"`
+	// Display maps each player's MMR into a display object next to the raw MMR
+	Display *MMRDisplayMapping `json:"display,omitempty"`
+	// TierLadder assigns each player a tier such as "Gold II" from their MMR,
+	// keeping their previousTier within the ladder's hysteresis
+	TierLadder *MMRTierLadder `json:"tierLadder,omitempty"`
 }
 
 type MMRCalculationTeam struct {
@@ -10,9 +41,117 @@ type MMRCalculationTeam struct {
 	Players []MMRCalculationPlayerRating `json:"players" binding:"required"`
 }
 
+// MMRCalculationRequestV2 describes a match between any number of teams.
+type MMRCalculationRequestV2 struct {
+	Teams []MMRCalculationTeamV2 `json:"teams" binding:"required"`
+	MMRCalculationOptions
+}
+
+// MMRCalculationTeamV2 has either a Score (higher is better) or a Rank
+// (1 is first place). Teams with equal values tied.
+type MMRCalculationTeamV2 struct {
+	Score   *int                         `json:"score"`
+	Rank    *int                         `json:"rank"`
+	Players []MMRCalculationPlayerRating `json:"players" binding:"required"`
+}
+
+// MMRMarginModel makes bigger wins move ratings further. A one-point win has
+// a multiplier of 1, which grows with the margin along the curve.
+type MMRMarginModel struct {
+	Curve string   `json:"curve,omitempty"` // linear, log or sqrt; defaults to log
+	Scale *float64 `json:"scale"`           // How fast the multiplier grows; defaults to 0.5
+	Cap   *float64 `json:"cap"`             // Largest multiplier; defaults to 2
+}
+
+// MMRCarryOverPolicy starts players flagged with isPreviousSeasonRating from
+// a rating derived from their previous season.
+type MMRCarryOverPolicy struct {
+	Policy         string   `json:"policy,omitempty"` // fraction-of-delta, full, hard-reset or regress-to-mean; defaults to fraction-of-delta
+	Fraction       *float64 `json:"fraction"`         // Share of the distance from the default (or league mean) kept; defaults to 1/3
+	SigmaInflation *float64 `json:"sigmaInflation"`   // Factor sigma grows by under full carry-over; defaults to 1.5
+	LeagueMean     *float64 `json:"leagueMean"`       // Mu ratings regress towards; required by regress-to-mean
+}
+
+// MMRDisplayMapping turns a player's MMR into what players see. Every mapping
+// starts from scale * mmr + offset.
+type MMRDisplayMapping struct {
+	Mapping string           `json:"mapping" binding:"required"` // linear, clamped, percentile or tiers
+	Scale   *float64         `json:"scale"`                      // Defaults to 1
+	Offset  *float64         `json:"offset"`                     // Defaults to 0
+	Min     *float64         `json:"min"`                        // clamped only; defaults to 0
+	Max     *float64         `json:"max"`                        // clamped only; defaults to no limit
+	Mean    *float64         `json:"mean"`                       // percentile only; the league's average value
+	StdDev  *float64         `json:"stdDev"`                     // percentile only; the spread of the league's values
+	Tiers   []MMRDisplayTier `json:"tiers"`                      // tiers only; lowest first
+}
+
+type MMRDisplayTier struct {
+	Name string  `json:"name" binding:"required"` // e.g. Bronze, Silver or Gold
+	Min  float64 `json:"min"`                     // Lowest value in the tier
+}
+
+// MMRTierLadder splits MMR into tiers and divisions, e.g. "Gold II".
+type MMRTierLadder struct {
+	Tiers      []MMRDisplayTier `json:"tiers" binding:"required"` // Lowest first; thresholds are in display value, or MMR without a display mapping
+	Divisions  int              `json:"divisions"`                // Divisions per tier except the top one, at most 5; defaults to 1
+	Hysteresis float64          `json:"hysteresis"`               // Distance past a division's edges, in the tiers' units, needed to leave it; defaults to 0
+}
+
 type MMRCalculationPlayerRating struct {
-	Id                     int64    `json:"id" binding:"required"`
-	Mu                     *float64 `json:"mu"`    // Use pointers to represent nullable values
-	Sigma                  *float64 `json:"sigma"` // Use pointers to represent nullable values
+	Id int64 `json:"id" binding:"required"`
+	// Mu is the rating; the Elo rating for elo and the Glicko-2 rating for
+	// glicko2. Pointers represent nullable values
+	Mu *float64 `json:"mu"`
+	// Sigma is the rating's uncertainty; the rating deviation for glicko2
+	Sigma *float64 `json:"sigma"`
+	// IsPreviousSeasonRating starts the player from mu and sigma as set by
+	// the request's carryOver policy
 	IsPreviousSeasonRating *bool    `json:"isPreviousSeasonRating"`
+	Volatility             *float64 `json:"volatility"`      // Glicko-2 only; defaults to 0.06
+	InactivePeriods        *int     `json:"inactivePeriods"` // Glicko-2 only; rating periods since the player's last match
+	Weight                 *float64 `json:"weight"`          // Share of the match played, in (0, 1]; defaults to 1
+	PreviousTier           *string  `json:"previousTier"`    // Tier before this match, so it can be kept within the ladder's hysteresis
+}
+
+// MMRBatchRequest is the object form of a batch request. A plain array of
+// matches is still accepted and answered with a plain array of results.
+type MMRBatchRequest struct {
+	Matches []MMRCalculationRequest `json:"matches" binding:"required"` // Calculated and validated in order
+	// InitialRatings are each player's rating before the first match they
+	// play in the batch, so matches only need to reference players by ID
+	InitialRatings []MMRCalculationPlayerRating `json:"initialRatings,omitempty" binding:"dive"`
+	// Strict rejects matches that give a player a rating other than the one
+	// they have in the batch, instead of ignoring it
+	Strict bool `json:"strict,omitempty"`
+	// ContinueOnError skips invalid matches instead of failing the batch. A
+	// skipped match doesn't change any player's rating
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+	// IncludeStandings adds every player's final state to the response
+	IncludeStandings bool `json:"includeStandings,omitempty"`
+	// Callback is sent a summary once the batch has finished
+	Callback *MMRCallback `json:"callback,omitempty"`
+}
+
+// MMRCallback is where to send a signed MMRCompletionSummary once a batch or
+// job has finished. Failed deliveries are retried with exponential backoff.
+type MMRCallback struct {
+	Url string `json:"url" binding:"required"` // Absolute http or https URL
+}
+
+// MMRPredictionRequest describes a match that hasn't been played yet.
+type MMRPredictionRequest struct {
+	Team1 MMRPredictionTeam `json:"team1" binding:"required"`
+	Team2 MMRPredictionTeam `json:"team2" binding:"required"`
+}
+
+type MMRPredictionTeam struct {
+	Players []MMRCalculationPlayerRating `json:"players" binding:"required"`
+}
+
+// MatchmakingBalanceRequest asks for the fairest way to split a pool of
+// players into two teams.
+type MatchmakingBalanceRequest struct {
+	Players  []MMRCalculationPlayerRating `json:"players" binding:"required"` // Exactly twice teamSize players
+	TeamSize int                          `json:"teamSize" binding:"required"`
+	Top      int                          `json:"top,omitempty"` // Number of splits to return, best first; defaults to 1
 }
@@ -1,8 +1,16 @@
 package view
 
+import "time"
+
 type MMRCalculationResponse struct {
-	Team1 MMRTeamResult `json:"team1" binding:"required"`
-	Team2 MMRTeamResult `json:"team2" binding:"required"`
+	Team1     MMRTeamResult   `json:"team1" binding:"required"`
+	Team2     MMRTeamResult   `json:"team2" binding:"required"`
+	Algorithm string          `json:"algorithm" binding:"required"` // Rating algorithm that produced the result
+	Profile   string          `json:"profile,omitempty"`            // Rating profile the request used
+	Outcome   MMRMatchOutcome `json:"outcome" binding:"required"`
+	// CarryOver is the policy applied to previous season ratings; only set
+	// when the match had any
+	CarryOver *MMRCarryOverPolicy `json:"carryOver,omitempty"`
 }
 
 type MMRTeamResult struct {
@@ -10,9 +18,170 @@ type MMRTeamResult struct {
 	Players []PlayerMMRResult `json:"players" binding:"required"`
 }
 
+type MMRCalculationResponseV2 struct {
+	Teams     []MMRTeamResultV2 `json:"teams" binding:"required"` // In request order
+	Algorithm string            `json:"algorithm" binding:"required"`
+	Profile   string            `json:"profile,omitempty"`
+	Outcome   MMRMatchOutcome   `json:"outcome" binding:"required"`
+	// CarryOver is the policy applied to previous season ratings; only set
+	// when the match had any
+	CarryOver *MMRCarryOverPolicy `json:"carryOver,omitempty"`
+}
+
+// MMRBatchResponse answers an MMRBatchRequest.
+type MMRBatchResponse struct {
+	Results []*MMRCalculationResponse `json:"results" binding:"required"` // In match order; null for skipped matches
+	// Errors lists every skipped match; only set with continueOnError
+	Errors []MMRBatchError `json:"errors,omitempty"`
+	// Standings is only set when the request asked for it; highest MMR first
+	Standings []MMRPlayerStanding `json:"standings,omitempty"`
+}
+
+type MMRBatchError struct {
+	BatchIndex int    `json:"batchIndex" binding:"required"`
+	Error      string `json:"error" binding:"required"`
+	// Problems lists every problem with the match, with pointers relative to
+	// it, when it failed validation
+	Problems []MMRValidationProblem `json:"problems,omitempty"`
+}
+
+// MMRPlayerStanding is a player's state after the last match of a batch they
+// played in.
+type MMRPlayerStanding struct {
+	Id          int64   `json:"id" binding:"required"`
+	Mu          float64 `json:"mu" binding:"required"`
+	Sigma       float64 `json:"sigma" binding:"required"`
+	MMR         int     `json:"mmr" binding:"required"`
+	GamesPlayed int     `json:"gamesPlayed" binding:"required"`
+	Wins        int     `json:"wins" binding:"required"`
+	Losses      int     `json:"losses" binding:"required"`
+	Draws       int     `json:"draws" binding:"required"`
+	PeakMMR     int     `json:"peakMmr" binding:"required"` // Highest MMR after any match in the batch
+}
+
+// MMRMatchOutcome records how the match result was interpreted.
+type MMRMatchOutcome struct {
+	Ranks  []int  `json:"ranks" binding:"required"`  // Finishing position per team in request order; 1 is first and tied teams share a rank
+	Draw   bool   `json:"draw" binding:"required"`   // Every team tied
+	Reason string `json:"reason" binding:"required"` // What decided the result: scores, ranks, draw-margin or explicit
+	// MarginMultiplier is the factor mu changes were scaled by; only set when
+	// the request had a margin model
+	MarginMultiplier float64 `json:"marginMultiplier,omitempty"`
+}
+
+type MMRTeamResultV2 struct {
+	Score   *int              `json:"score,omitempty"`
+	Rank    *int              `json:"rank,omitempty"`
+	Players []PlayerMMRResult `json:"players" binding:"required"`
+}
+
 type PlayerMMRResult struct {
 	Id    int64   `json:"id" binding:"required"`
 	Mu    float64 `json:"mu" binding:"required"`    // Required in the response
 	Sigma float64 `json:"sigma" binding:"required"` // Required in the response
 	MMR   int     `json:"mmr" binding:"required"`   // New field in the response
+	// Volatility is only set by algorithms that track it (Glicko-2)
+	Volatility *float64 `json:"volatility,omitempty"`
+	// Display is the MMR mapped for players; only set when the request or
+	// its profile has a display mapping
+	Display *MMRDisplayValue `json:"display,omitempty"`
+	// Tier is only set when the request or its profile has a tier ladder
+	Tier string `json:"tier,omitempty"`
+	// Input is the rating the calculation started from, after defaults and
+	// previous season carry-over
+	Input MMRRatingSnapshot `json:"input" binding:"required"`
+	// Delta is the new rating minus Input; the MMR delta matches the MMRs
+	// shown, e.g. +23
+	Delta          MMRRatingSnapshot `json:"delta" binding:"required"`
+	WinProbability float64           `json:"winProbability" binding:"required"` // The player's team's chance of winning, before the match
+}
+
+type MMRRatingSnapshot struct {
+	Mu    float64 `json:"mu" binding:"required"`
+	Sigma float64 `json:"sigma" binding:"required"`
+	MMR   int     `json:"mmr" binding:"required"`
+}
+
+type MMRDisplayValue struct {
+	Value float64 `json:"value" binding:"required"`
+	Label string  `json:"label,omitempty"` // Tier name; only set by the tiers mapping
+}
+
+type MMRPredictionResponse struct {
+	Team1           MMRTeamPrediction `json:"team1" binding:"required"`
+	Team2           MMRTeamPrediction `json:"team2" binding:"required"`
+	DrawProbability float64           `json:"drawProbability" binding:"required"` // Chance the match ends level
+	MatchQuality    float64           `json:"matchQuality" binding:"required"`    // 0 to 1; higher is more balanced
+}
+
+type MMRTeamPrediction struct {
+	WinProbability float64 `json:"winProbability" binding:"required"` // The two teams' win probabilities sum to 1
+}
+
+type MatchmakingBalanceResponse struct {
+	Splits []MatchmakingSplit `json:"splits" binding:"required"` // Best first
+}
+
+type MatchmakingSplit struct {
+	Teams        []MatchmakingTeam `json:"teams" binding:"required"`
+	MatchQuality float64           `json:"matchQuality" binding:"required"` // 0 to 1; higher is more balanced
+}
+
+type MatchmakingTeam struct {
+	PlayerIds      []int64 `json:"playerIds" binding:"required"` // In pool order
+	WinProbability float64 `json:"winProbability" binding:"required"`
+}
+
+// MMRReplayLine is one line of a replay response: the result of the match at
+// Index, or the error that ended the replay there.
+type MMRReplayLine struct {
+	Index  int                     `json:"index" binding:"required"`
+	Result *MMRCalculationResponse `json:"result,omitempty"`
+	Error  string                  `json:"error,omitempty"`
+}
+
+// MMRValidationResponse lists every problem found in a calculation request.
+type MMRValidationResponse struct {
+	Valid    bool                   `json:"valid" binding:"required"`
+	Problems []MMRValidationProblem `json:"problems" binding:"required"`
+}
+
+type MMRValidationProblem struct {
+	Pointer string `json:"pointer" binding:"required"` // JSON pointer to the offending value, e.g. /matches/2/team1/players/0/sigma
+	Message string `json:"message" binding:"required"`
+}
+
+// MMRJobStatus is the state of an asynchronous batch calculation.
+type MMRJobStatus struct {
+	Id         string     `json:"id" binding:"required"`
+	Status     string     `json:"status" binding:"required"`    // running, succeeded, failed or cancelled
+	Processed  int        `json:"processed" binding:"required"` // Matches processed so far
+	Total      int        `json:"total" binding:"required"`     // Matches in the batch
+	Error      string     `json:"error,omitempty"`              // Why the job failed or was cancelled
+	BatchIndex *int       `json:"batchIndex,omitempty"`         // The match that failed the job, if any
+	CreatedAt  time.Time  `json:"createdAt" binding:"required"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Deliveries are the attempts so far to deliver the job's callback
+	Deliveries []MMRDeliveryAttempt `json:"deliveries,omitempty"`
+}
+
+type MMRDeliveryAttempt struct {
+	At         time.Time `json:"at" binding:"required"`
+	StatusCode int       `json:"statusCode,omitempty"` // Not set if the receiver didn't respond
+	Error      string    `json:"error,omitempty"`      // Not set if the receiver accepted the callback
+}
+
+// MMRCompletionSummary is POSTed to a batch's or job's callback once it has
+// finished. The X-MMR-Signature header holds "sha256=" and the hex
+// HMAC-SHA256 of the X-MMR-Timestamp header, a dot and the body, keyed with
+// the webhook secret.
+type MMRCompletionSummary struct {
+	JobId      string    `json:"jobId,omitempty"`              // Only set for jobs
+	Status     string    `json:"status" binding:"required"`    // succeeded, failed or cancelled
+	Processed  int       `json:"processed" binding:"required"` // Matches processed
+	Total      int       `json:"total" binding:"required"`     // Matches in the batch
+	Skipped    int       `json:"skipped"`                      // Matches skipped with continueOnError
+	Error      string    `json:"error,omitempty"`              // Why the batch failed or was cancelled
+	BatchIndex *int      `json:"batchIndex,omitempty"`         // The match that failed the batch, if any
+	FinishedAt time.Time `json:"finishedAt" binding:"required"`
 }
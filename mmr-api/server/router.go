@@ -1,9 +1,14 @@
 package server
 
 import (
+	"mmr/backend/apikey"
 	"mmr/backend/controllers"
+	"mmr/backend/jobs"
+	"mmr/backend/jwtauth"
 	"mmr/backend/middleware"
+	"mmr/backend/signing"
 	"mmr/backend/telemetry"
+	"mmr/backend/webhook"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +17,16 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func NewRouter() *gin.Engine {
+// Services are the long-lived dependencies shared by the routes.
+type Services struct {
+	Jobs     *jobs.Manager
+	Webhooks *webhook.Dispatcher // Nil refuses callbacks
+	APIKeys  *apikey.Store
+	Tokens   *jwtauth.Verifier   // Nil refuses bearer tokens
+	Nonces   *signing.NonceCache // Nil refuses signed requests
+}
+
+func NewRouter(services Services) *gin.Engine {
 	router := gin.New()
 	// Skip tracing for the health probe; frequent liveness/readiness polls would
 	// otherwise flood the trace backend (the access log skips it too).
@@ -22,13 +36,49 @@ func NewRouter() *gin.Engine {
 	router.Use(middleware.AccessLog())
 	router.Use(gin.Recovery())
 
+	auth := middleware.Authenticator{Keys: services.APIKeys, Tokens: services.Tokens, Nonces: services.Nonces}
+	requireCalculate := auth.Require(apikey.ScopeCalculate)
+	requirePredict := auth.Require(apikey.ScopePredict)
+
 	v1 := router.Group("/api/v1")
 	{
-		calc := v1.Group("/mmr-calculation", middleware.RequireAdminAuth)
+		calc := v1.Group("/mmr-calculation", requireCalculate)
 		{
-			calculation := new(controllers.CalculationController)
+			calculation := &controllers.CalculationController{Webhooks: services.Webhooks}
 			calc.POST("", calculation.SubmitMMRCalculation)
 			calc.POST("/batch", calculation.SubmitMMRCalculationsBatch)
+			calc.POST("/replay", calculation.ReplayMMRCalculations)
+			calc.POST("/validate", calculation.ValidateMMRCalculations)
+		}
+
+		predict := v1.Group("/mmr-prediction", requirePredict)
+		{
+			prediction := new(controllers.PredictionController)
+			predict.POST("", prediction.SubmitMMRPrediction)
+		}
+
+		matchmaking := v1.Group("/matchmaking", requirePredict)
+		{
+			balance := new(controllers.MatchmakingController)
+			matchmaking.POST("/balance", balance.BalanceTeams)
+		}
+
+		jobGroup := v1.Group("/jobs", requireCalculate)
+		{
+			job := &controllers.JobController{Jobs: services.Jobs, Webhooks: services.Webhooks}
+			jobGroup.POST("/mmr-calculation/batch", job.SubmitMMRCalculationsBatchJob)
+			jobGroup.GET("/:id", job.GetJob)
+			jobGroup.GET("/:id/results", job.GetJobResults)
+			jobGroup.POST("/:id/cancel", job.CancelJob)
+		}
+	}
+
+	v2 := router.Group("/api/v2")
+	{
+		calc := v2.Group("/mmr-calculation", requireCalculate)
+		{
+			calculation := &controllers.CalculationController{Webhooks: services.Webhooks}
+			calc.POST("", calculation.SubmitMMRCalculationV2)
 		}
 	}
 
@@ -3,13 +3,39 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"mmr/backend/apikey"
+	"mmr/backend/config"
+	"mmr/backend/jobs"
+	"mmr/backend/jwtauth"
+	"mmr/backend/signing"
+	"mmr/backend/webhook"
 	"net/http"
+	"net/netip"
 	"os"
 	"time"
 )
 
-func Init(ctx context.Context) error {
-	router := NewRouter()
+func Init(ctx context.Context, cfg config.Config, apiKeys *apikey.Store, tokens *jwtauth.Verifier) error {
+	// Jobs outlive the requests that start them, so they stop with the server
+	jobManager := jobs.NewManager(ctx)
+	jobManager.MaxActive = cfg.MaxActiveJobs
+	var allowedNetworks []netip.Prefix
+	for _, network := range cfg.WebhookAllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf("invalid WEBHOOK_ALLOWED_NETWORKS entry: %w", err)
+		}
+		allowedNetworks = append(allowedNetworks, prefix)
+	}
+	webhooks := webhook.NewDispatcher(ctx, webhook.NewClient(allowedNetworks))
+	router := NewRouter(Services{
+		Jobs:     jobManager,
+		Webhooks: webhooks,
+		APIKeys:  apiKeys,
+		Tokens:   tokens,
+		Nonces:   signing.NewNonceCache(),
+	})
 	port := os.Getenv("MMR_API_PORT")
 	if port == "" {
 		port = "8080"
@@ -38,6 +64,10 @@ func Init(ctx context.Context) error {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		shutdownErr := srv.Shutdown(shutdownCtx)
+		// Running jobs and callback retries were cancelled along with ctx;
+		// wait for them to stop
+		jobManager.Wait()
+		webhooks.Wait()
 		// Drain serverErr: the goroutine either sent a real error (ok=true) or
 		// closed the channel after a clean ErrServerClosed (ok=false). A real
 		// error from ListenAndServe is more informative than Shutdown's reply.
@@ -0,0 +1,163 @@
+// Package signing verifies requests signed with an API key, so the key
+// itself is never sent and a captured request can't be replayed.
+package signing
+
+import (
+	"container/heap"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// KeyIDHeader names the API key the request is signed with.
+	KeyIDHeader = "X-MMR-Key-Id"
+	// TimestampHeader holds the Unix time the request was signed at.
+	TimestampHeader = "X-MMR-Timestamp"
+	// NonceHeader holds a value unique to the request, such as a random UUID.
+	NonceHeader = "X-MMR-Nonce"
+	// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, nonce, method, path with query and body, each followed by a
+	// newline except the body, keyed with the API key.
+	SignatureHeader = "X-MMR-Signature"
+)
+
+const (
+	// DefaultMaxSkew is how far a request's timestamp may be from now.
+	DefaultMaxSkew = 5 * time.Minute
+	// DefaultNonceCapacity bounds how many nonces are remembered.
+	DefaultNonceCapacity = 100_000
+	// MaxNonceLength bounds the size of a nonce.
+	MaxNonceLength = 128
+	// MaxBodySize bounds the body of a signed request, which is read whole
+	// to check the signature before the request is handled.
+	MaxBodySize = 10 << 20
+)
+
+var (
+	ErrStale    = errors.New("request timestamp is too old or too far in the future")
+	ErrReplayed = errors.New("request nonce has already been used")
+)
+
+// Sign returns the signature of a request.
+func Sign(key []byte, timestamp string, nonce string, method string, path string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range []string{timestamp, nonce, method, path} {
+		mac.Write([]byte(field))
+		mac.Write([]byte("\n"))
+	}
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a request.
+func Verify(key []byte, timestamp string, nonce string, method string, path string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(key, timestamp, nonce, method, path, body)), []byte(signature))
+}
+
+// ParseTimestamp parses a TimestampHeader value.
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// NonceCache remembers the nonces of recent requests to refuse replays.
+// Nonces are kept per key, so one client can't use up another's. Requests
+// are only accepted within MaxSkew of now, so nonces are forgotten
+// once their timestamp is that old. When the cache is full the nonce with
+// the oldest timestamp is forgotten early, and requests signed at or before
+// it are refused from then on, so no replay is let through.
+type NonceCache struct {
+	MaxSkew  time.Duration
+	Capacity int
+
+	mu     sync.Mutex
+	seen   map[nonceKey]struct{}
+	byTime nonceHeap
+	floor  time.Time // Requests signed at or before this are refused
+}
+
+// NewNonceCache returns a NonceCache with the default skew and capacity.
+func NewNonceCache() *NonceCache {
+	return &NonceCache{MaxSkew: DefaultMaxSkew, Capacity: DefaultNonceCapacity}
+}
+
+// Use checks that a request signed with keyID at timestamp is recent and
+// that nonce hasn't been used with keyID before, and remembers it. Only call
+// it for requests whose signature is valid, so forged requests can't fill
+// the cache.
+func (n *NonceCache) Use(keyID string, nonce string, timestamp time.Time, now time.Time) error {
+	if timestamp.Before(now.Add(-n.MaxSkew)) || timestamp.After(now.Add(n.MaxSkew)) {
+		return ErrStale
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.seen == nil {
+		n.seen = make(map[nonceKey]struct{})
+	}
+	for len(n.byTime) > 0 && n.byTime[0].timestamp.Before(now.Add(-n.MaxSkew)) {
+		delete(n.seen, heap.Pop(&n.byTime).(nonceEntry).nonceKey)
+	}
+	if !timestamp.After(n.floor) {
+		return ErrStale
+	}
+	used := nonceKey{keyID: keyID, nonce: nonce}
+	if _, seen := n.seen[used]; seen {
+		return ErrReplayed
+	}
+
+	if len(n.byTime) > 0 && len(n.byTime) >= n.Capacity && !timestamp.After(n.byTime[0].timestamp) {
+		// The cache is full of requests signed no earlier than this one
+		return ErrStale
+	}
+	for len(n.byTime) > 0 && len(n.byTime) >= n.Capacity {
+		evicted := heap.Pop(&n.byTime).(nonceEntry)
+		delete(n.seen, evicted.nonceKey)
+		if evicted.timestamp.After(n.floor) {
+			n.floor = evicted.timestamp
+		}
+	}
+	n.seen[used] = struct{}{}
+	heap.Push(&n.byTime, nonceEntry{nonceKey: used, timestamp: timestamp})
+	return nil
+}
+
+// Len returns how many nonces are remembered.
+func (n *NonceCache) Len() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.byTime)
+}
+
+type nonceKey struct {
+	keyID string
+	nonce string
+}
+
+type nonceEntry struct {
+	nonceKey
+	timestamp time.Time
+}
+
+// nonceHeap orders nonces by timestamp, oldest first.
+type nonceHeap []nonceEntry
+
+func (h nonceHeap) Len() int           { return len(h) }
+func (h nonceHeap) Less(i, j int) bool { return h[i].timestamp.Before(h[j].timestamp) }
+func (h nonceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nonceHeap) Push(x any)        { *h = append(*h, x.(nonceEntry)) }
+func (h *nonceHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
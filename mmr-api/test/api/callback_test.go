@@ -0,0 +1,174 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/apikey"
+	"mmr/backend/controllers"
+	"mmr/backend/jobs"
+	view "mmr/backend/models"
+	"mmr/backend/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var webhookSecret = []byte("webhook secret")
+
+// testWebhooks delivers to the loopback addresses test receivers listen on.
+func testWebhooks() *webhook.Dispatcher {
+	client := webhook.NewClient([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	return webhook.NewDispatcher(context.Background(), client)
+}
+
+// authenticatedAs makes routes registered after it behave as if requests
+// authenticated with key.
+func authenticatedAs(router *gin.Engine, key apikey.Key) {
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(apikey.NewContext(c.Request.Context(), key))
+	})
+}
+
+func callbackKey() apikey.Key {
+	key := apikey.NewKey("callbacks", "secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{})
+	key.WebhookSecret = webhookSecret
+	return key
+}
+
+// callbackReceiver collects the verified summaries sent to it.
+func callbackReceiver(t *testing.T) (*httptest.Server, chan view.MMRCompletionSummary) {
+	summaries := make(chan view.MMRCompletionSummary, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify(webhookSecret, r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var summary view.MMRCompletionSummary
+		assert.NoError(t, json.Unmarshal(body, &summary))
+		summaries <- summary
+	}))
+	t.Cleanup(server.Close)
+	return server, summaries
+}
+
+func setupCallbackRouter(key apikey.Key) *gin.Engine {
+	webhooks := testWebhooks()
+	router := setupRouter()
+	authenticatedAs(router, key)
+	calculationController := controllers.CalculationController{Webhooks: webhooks}
+	jobController := controllers.JobController{Jobs: jobs.NewManager(context.Background()), Webhooks: webhooks}
+	router.POST("/v1/mmr-calculation/batch", calculationController.SubmitMMRCalculationsBatch)
+	router.POST("/v1/jobs/mmr-calculation/batch", jobController.SubmitMMRCalculationsBatchJob)
+	router.GET("/v1/jobs/:id", jobController.GetJob)
+	return router
+}
+
+func receiveSummary(t *testing.T, summaries chan view.MMRCompletionSummary) view.MMRCompletionSummary {
+	select {
+	case summary := <-summaries:
+		return summary
+	case <-time.After(time.Second):
+		t.Fatal("no callback received")
+		return view.MMRCompletionSummary{}
+	}
+}
+
+func TestBatchCallback(t *testing.T) {
+	server, summaries := callbackReceiver(t)
+	router := setupCallbackRouter(callbackKey())
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches:         []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), batchMatch(10, 5, 1, 1)},
+		ContinueOnError: true,
+		Callback:        &view.MMRCallback{Url: server.URL},
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	summary := receiveSummary(t, summaries)
+	assert.Equal(t, "succeeded", summary.Status)
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Empty(t, summary.JobId)
+}
+
+func TestJobCallbackRecordsDeliveries(t *testing.T) {
+	server, summaries := callbackReceiver(t)
+	router := setupCallbackRouter(callbackKey())
+
+	job := startJob(t, router, view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), batchMatch(10, 5, 2, 2)},
+		Callback: &view.MMRCallback{Url: server.URL},
+	})
+
+	summary := receiveSummary(t, summaries)
+	assert.Equal(t, job.Id, summary.JobId)
+	assert.Equal(t, "failed", summary.Status)
+	if assert.NotNil(t, summary.BatchIndex) {
+		assert.Equal(t, 1, *summary.BatchIndex)
+	}
+
+	assert.Eventually(t, func() bool {
+		job = waitForJob(t, router, job.Id)
+		return len(job.Deliveries) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, http.StatusOK, job.Deliveries[0].StatusCode)
+	assert.Empty(t, job.Deliveries[0].Error)
+}
+
+func TestCallbackRejectedWithoutWebhooks(t *testing.T) {
+	router := setupBatchRouter()
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+		Callback: &view.MMRCallback{Url: "http://localhost/callback"},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "not configured")
+}
+
+func TestCallbackRejectedWithoutKeyWebhookSecret(t *testing.T) {
+	server, _ := callbackReceiver(t)
+	router := setupCallbackRouter(apikey.NewKey("no-callbacks", "secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{}))
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+		Callback: &view.MMRCallback{Url: server.URL},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "webhook secret")
+}
+
+func TestCallbackRejectedToPrivateAddress(t *testing.T) {
+	router := setupCallbackRouter(callbackKey())
+
+	for _, url := range []string{"http://10.0.0.1/callback", "http://169.254.169.254/latest/meta-data", "http://[::1]/callback"} {
+		rr := postRequest(router, "/v1/jobs/mmr-calculation/batch", view.MMRBatchRequest{
+			Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+			Callback: &view.MMRCallback{Url: url},
+		})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, url)
+	}
+}
+
+func TestCallbackInvalidURL(t *testing.T) {
+	router := setupCallbackRouter(callbackKey())
+
+	rr := postRequest(router, "/v1/jobs/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+		Callback: &view.MMRCallback{Url: "callback"},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,158 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	"mmr/backend/jobs"
+	"mmr/backend/middleware"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupJobRouter() *gin.Engine {
+	return jobRouterAs(controllers.JobController{Jobs: jobs.NewManager(context.Background())}, "")
+}
+
+// jobRouterAs routes to jobController as if requests had authenticated with
+// the API key named keyName.
+func jobRouterAs(jobController controllers.JobController, keyName string) *gin.Engine {
+	router := setupRouter()
+	if keyName != "" {
+		router.Use(func(c *gin.Context) {
+			c.Set(middleware.KeyNameKey, keyName)
+		})
+	}
+	router.POST("/v1/jobs/mmr-calculation/batch", jobController.SubmitMMRCalculationsBatchJob)
+	router.GET("/v1/jobs/:id", jobController.GetJob)
+	router.GET("/v1/jobs/:id/results", jobController.GetJobResults)
+	router.POST("/v1/jobs/:id/cancel", jobController.CancelJob)
+	return router
+}
+
+func getRequest(router *gin.Engine, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func startJob(t *testing.T, router *gin.Engine, requestBody interface{}) view.MMRJobStatus {
+	rr := postRequest(router, "/v1/jobs/mmr-calculation/batch", requestBody)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	var job view.MMRJobStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, "/api/v1/jobs/"+job.Id, rr.Header().Get("Location"))
+	return job
+}
+
+func waitForJob(t *testing.T, router *gin.Engine, id string) view.MMRJobStatus {
+	var job view.MMRJobStatus
+	assert.Eventually(t, func() bool {
+		rr := getRequest(router, "/v1/jobs/"+id)
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+		return job.FinishedAt != nil
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+// TestBatchJobMatchesBatch verifies a job's results are those of the
+// synchronous batch endpoint.
+func TestBatchJobMatchesBatch(t *testing.T) {
+	router := setupJobRouter()
+	requestBody := view.MMRBatchRequest{
+		Matches:          []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), batchMatch(10, 5, 1, 3)},
+		IncludeStandings: true,
+	}
+
+	job := startJob(t, router, requestBody)
+	assert.Equal(t, 2, job.Total)
+
+	job = waitForJob(t, router, job.Id)
+	assert.Equal(t, "succeeded", job.Status)
+	assert.Equal(t, 2, job.Processed)
+
+	rr := getRequest(router, "/v1/jobs/"+job.Id+"/results")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var results view.MMRBatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+
+	batch := postRequest(setupBatchRouter(), "/v1/mmr-calculation/batch", requestBody)
+	assert.JSONEq(t, batch.Body.String(), rr.Body.String())
+	assert.Equal(t, 3, len(results.Standings))
+}
+
+func TestBatchJobFailure(t *testing.T) {
+	router := setupJobRouter()
+
+	job := startJob(t, router, []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), batchMatch(10, 5, 1, 1)})
+
+	job = waitForJob(t, router, job.Id)
+	assert.Equal(t, "failed", job.Status)
+	assert.Contains(t, job.Error, "duplicated")
+	if assert.NotNil(t, job.BatchIndex) {
+		assert.Equal(t, 1, *job.BatchIndex)
+	}
+
+	rr := getRequest(router, "/v1/jobs/"+job.Id+"/results")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestBatchJobNotFound(t *testing.T) {
+	router := setupJobRouter()
+
+	assert.Equal(t, http.StatusNotFound, getRequest(router, "/v1/jobs/unknown").Code)
+	assert.Equal(t, http.StatusNotFound, postRequest(router, "/v1/jobs/unknown/cancel", nil).Code)
+}
+
+func TestBatchJobInvalidBody(t *testing.T) {
+	router := setupJobRouter()
+
+	rr := postRequest(router, "/v1/jobs/mmr-calculation/batch", "not a batch")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// TestBatchJobHiddenFromOtherKeys verifies a job can only be seen and
+// cancelled with the key that started it.
+func TestBatchJobHiddenFromOtherKeys(t *testing.T) {
+	jobController := controllers.JobController{Jobs: jobs.NewManager(context.Background())}
+	owner := jobRouterAs(jobController, "backfill")
+	other := jobRouterAs(jobController, "dashboard")
+
+	job := startJob(t, owner, []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)})
+
+	assert.Equal(t, http.StatusNotFound, getRequest(other, "/v1/jobs/"+job.Id).Code)
+	assert.Equal(t, http.StatusNotFound, getRequest(other, "/v1/jobs/"+job.Id+"/results").Code)
+	assert.Equal(t, http.StatusNotFound, postRequest(other, "/v1/jobs/"+job.Id+"/cancel", nil).Code)
+
+	job = waitForJob(t, owner, job.Id)
+	assert.Equal(t, "succeeded", job.Status)
+}
+
+func TestBatchJobLimit(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+	manager.MaxActive = 1
+	router := jobRouterAs(controllers.JobController{Jobs: manager}, "backfill")
+	blocked := make(chan struct{})
+	running, _ := manager.Submit(context.Background(), "key:backfill", 1, func(ctx context.Context, progress func(int)) (any, error) {
+		<-blocked
+		return nil, nil
+	}, nil)
+
+	rr := postRequest(router, "/v1/jobs/mmr-calculation/batch", []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)})
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	close(blocked)
+	waitForJob(t, router, running.ID)
+	startJob(t, router, []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)})
+}
@@ -0,0 +1,66 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMatchmakingRouter() *gin.Engine {
+	router := setupRouter()
+	matchmakingController := controllers.MatchmakingController{}
+	router.POST("/matchmaking/balance", matchmakingController.BalanceTeams)
+	return router
+}
+
+func TestBalanceTeams(t *testing.T) {
+	router := setupMatchmakingRouter()
+
+	strong, weak, sigma := 35.0, 15.0, 4.0
+	requestBody := view.MatchmakingBalanceRequest{
+		Players: []view.MMRCalculationPlayerRating{
+			{Id: 10, Mu: &strong, Sigma: &sigma},
+			{Id: 20, Mu: &strong, Sigma: &sigma},
+			{Id: 30, Mu: &weak, Sigma: &sigma},
+			{Id: 40, Mu: &weak, Sigma: &sigma},
+		},
+		TeamSize: 2,
+		Top:      3,
+	}
+
+	rr := postRequest(router, "/matchmaking/balance", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MatchmakingBalanceResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, 3, len(response.Splits))
+	best := response.Splits[0]
+	assert.Equal(t, []int64{10, 30}, best.Teams[0].PlayerIds)
+	assert.Equal(t, []int64{20, 40}, best.Teams[1].PlayerIds)
+	assert.InDelta(t, 0.5, best.Teams[0].WinProbability, 1e-9)
+	// Pairing the two strong players is the only unfair split
+	assert.Equal(t, best.MatchQuality, response.Splits[1].MatchQuality)
+	assert.Greater(t, best.MatchQuality, response.Splits[2].MatchQuality)
+}
+
+func TestBalanceTeamsWrongPoolSize(t *testing.T) {
+	router := setupMatchmakingRouter()
+
+	requestBody := view.MatchmakingBalanceRequest{
+		Players:  []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2}, {Id: 3}},
+		TeamSize: 2,
+	}
+
+	rr := postRequest(router, "/matchmaking/balance", requestBody)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,264 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupBatchRouter() *gin.Engine {
+	router := setupRouter()
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation/batch", calculationController.SubmitMMRCalculationsBatch)
+	return router
+}
+
+func batchMatch(team1Score, team2Score int, team1, team2 int64) view.MMRCalculationRequest {
+	return view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: team1}}},
+		Team2: view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: team2}}},
+	}
+}
+
+// TestSubmitMMRCalculationsBatchStandings verifies the object form returns
+// every player's final state when asked for standings.
+func TestSubmitMMRCalculationsBatchStandings(t *testing.T) {
+	router := setupBatchRouter()
+
+	requestBody := view.MMRBatchRequest{
+		Matches: []view.MMRCalculationRequest{
+			batchMatch(10, 5, 1, 2),
+			batchMatch(10, 5, 1, 3),
+			batchMatch(4, 10, 1, 2),
+			batchMatch(7, 7, 2, 3),
+		},
+		IncludeStandings: true,
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRBatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, 4, len(response.Results))
+	assert.Equal(t, 3, len(response.Standings))
+
+	standings := make(map[int64]view.MMRPlayerStanding)
+	for i, standing := range response.Standings {
+		standings[standing.Id] = standing
+		if i > 0 {
+			assert.LessOrEqual(t, standing.MMR, response.Standings[i-1].MMR)
+		}
+	}
+
+	player1 := standings[1]
+	assert.Equal(t, 3, player1.GamesPlayed)
+	assert.Equal(t, 2, player1.Wins)
+	assert.Equal(t, 1, player1.Losses)
+	assert.Equal(t, response.Results[1].Team1.Players[0].MMR, player1.PeakMMR)
+	assert.Equal(t, response.Results[2].Team1.Players[0].MMR, player1.MMR)
+	assert.Equal(t, response.Results[2].Team1.Players[0].Mu, player1.Mu)
+
+	player3 := standings[3]
+	assert.Equal(t, 2, player3.GamesPlayed)
+	assert.Equal(t, 1, player3.Losses)
+	assert.Equal(t, 1, player3.Draws)
+}
+
+// TestSubmitMMRCalculationsBatchObjectWithoutStandings verifies standings are
+// opt-in.
+func TestSubmitMMRCalculationsBatchObjectWithoutStandings(t *testing.T) {
+	router := setupBatchRouter()
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches: []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Contains(t, response, "results")
+	assert.NotContains(t, response, "standings")
+}
+
+func TestSubmitMMRCalculationsBatchInvalidBody(t *testing.T) {
+	router := setupBatchRouter()
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", "matches")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = postRequest(router, "/v1/mmr-calculation/batch", map[string]any{"matches": []map[string]any{{"team1": map[string]any{}}}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// TestSubmitMMRCalculationsBatchInitialRatings verifies seeded players start
+// from their initial rating when matches reference them by ID only.
+func TestSubmitMMRCalculationsBatchInitialRatings(t *testing.T) {
+	router := setupBatchRouter()
+
+	requestBody := view.MMRBatchRequest{
+		InitialRatings: []view.MMRCalculationPlayerRating{
+			{Id: 1, Mu: float64Ptr(30), Sigma: float64Ptr(3)},
+		},
+		Matches: []view.MMRCalculationRequest{
+			batchMatch(10, 5, 1, 2),
+			batchMatch(10, 5, 1, 2),
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRBatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, 30.0, response.Results[0].Team1.Players[0].Input.Mu)
+	assert.Equal(t, 3.0, response.Results[0].Team1.Players[0].Input.Sigma)
+	assert.Equal(t, 25.0, response.Results[0].Team2.Players[0].Input.Mu)
+	assert.Equal(t, response.Results[0].Team1.Players[0].Mu, response.Results[1].Team1.Players[0].Input.Mu)
+}
+
+func withRating(match view.MMRCalculationRequest, mu, sigma float64) view.MMRCalculationRequest {
+	match.Team1.Players = []view.MMRCalculationPlayerRating{{Id: match.Team1.Players[0].Id, Mu: &mu, Sigma: &sigma}}
+	return match
+}
+
+// TestSubmitMMRCalculationsBatchStrict verifies strict mode rejects ratings
+// that conflict with a player's rating in the batch, which are otherwise
+// ignored, and accepts equal ones.
+func TestSubmitMMRCalculationsBatchStrict(t *testing.T) {
+	router := setupBatchRouter()
+
+	tests := []struct {
+		name     string
+		matches  []view.MMRCalculationRequest
+		conflict bool
+	}{
+		{name: "differs from initial rating", matches: []view.MMRCalculationRequest{withRating(batchMatch(10, 5, 1, 3), 40, 3)}, conflict: true},
+		{name: "differs from earlier match", matches: []view.MMRCalculationRequest{batchMatch(10, 5, 2, 3), withRating(batchMatch(10, 5, 2, 3), 25, 5)}, conflict: true},
+		{name: "equals initial rating", matches: []view.MMRCalculationRequest{withRating(batchMatch(10, 5, 1, 3), 30, 3)}},
+		{name: "new player", matches: []view.MMRCalculationRequest{withRating(batchMatch(10, 5, 2, 3), 20, 4)}},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+				InitialRatings: []view.MMRCalculationPlayerRating{{Id: 1, Mu: float64Ptr(30), Sigma: float64Ptr(3)}},
+				Matches:        tt.matches,
+				Strict:         strict,
+			})
+
+			if strict && tt.conflict {
+				assert.Equal(t, http.StatusBadRequest, rr.Code, tt.name)
+				assert.Contains(t, rr.Body.String(), "batchIndex", tt.name)
+			} else {
+				assert.Equal(t, http.StatusOK, rr.Code, tt.name)
+			}
+		}
+	}
+}
+
+func TestSubmitMMRCalculationsBatchDuplicateInitialRating(t *testing.T) {
+	router := setupBatchRouter()
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		InitialRatings: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 1}},
+		Matches:        []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// TestSubmitMMRCalculationsBatchContinueOnError verifies invalid matches are
+// all reported and skipped without changing any player's rating.
+func TestSubmitMMRCalculationsBatchContinueOnError(t *testing.T) {
+	router := setupBatchRouter()
+
+	missingScore := batchMatch(10, 5, 1, 2)
+	missingScore.Team2.Score = nil
+	requestBody := view.MMRBatchRequest{
+		Matches: []view.MMRCalculationRequest{
+			batchMatch(10, 5, 1, 2),
+			batchMatch(10, 5, 1, 1),
+			missingScore,
+			batchMatch(10, 5, 1, 2),
+		},
+		ContinueOnError: true,
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRBatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, 4, len(response.Results))
+	assert.Nil(t, response.Results[1])
+	assert.Nil(t, response.Results[2])
+	assert.Equal(t, 2, len(response.Errors))
+	assert.Equal(t, 1, response.Errors[0].BatchIndex)
+	assert.Contains(t, response.Errors[0].Error, "duplicated")
+	assert.Equal(t, 2, response.Errors[1].BatchIndex)
+
+	// The skipped matches left player 1's rating alone
+	assert.Equal(t, response.Results[0].Team1.Players[0].Mu, response.Results[3].Team1.Players[0].Input.Mu)
+}
+
+func TestSubmitMMRCalculationsBatchStopsOnErrorByDefault(t *testing.T) {
+	router := setupBatchRouter()
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", view.MMRBatchRequest{
+		Matches: []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), batchMatch(10, 5, 1, 1)},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"batchIndex":1`)
+}
+
+func TestSubmitMMRCalculationsBatchReportsEveryProblem(t *testing.T) {
+	router := setupBatchRouter()
+
+	invalid := batchMatch(10, 5, 1, 1)
+	invalid.Algorithm = "glicko-3"
+	invalid.Team1.Players[0].Weight = float64Ptr(2)
+	requestBody := view.MMRBatchRequest{
+		Matches:         []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), invalid},
+		ContinueOnError: true,
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation/batch", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRBatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, 1, len(response.Errors))
+	pointers := make([]string, len(response.Errors[0].Problems))
+	for i, problem := range response.Errors[0].Problems {
+		pointers[i] = problem.Pointer
+	}
+	assert.ElementsMatch(t, []string{"/team2/players/0/id", "/team1/players/0/weight", "/algorithm"}, pointers)
+	assert.Contains(t, response.Errors[0].Error, "duplicated")
+	assert.Contains(t, response.Errors[0].Error, "glicko-3")
+
+	// Without continueOnError the failing match's problems are all reported too
+	requestBody.ContinueOnError = false
+	rr = postRequest(router, "/v1/mmr-calculation/batch", requestBody)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"pointer":"/algorithm"`)
+	assert.Contains(t, rr.Body.String(), `"pointer":"/team1/players/0/weight"`)
+}
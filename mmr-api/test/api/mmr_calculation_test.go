@@ -6,9 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"mmr/backend/controllers"
+	"mmr/backend/mmr"
 	view "mmr/backend/models"
 
 	"github.com/stretchr/testify/assert"
@@ -504,7 +507,7 @@ func TestSubmitMMRCalculation1v1(t *testing.T) {
 }
 
 // TestSubmitMMRCalculationMismatchedTeamSizesRejected verifies 1v2-style asymmetric
-// matches are rejected (we only support equal-sized teams).
+// matches are rejected when the request opts into equal-sized teams.
 func TestSubmitMMRCalculationMismatchedTeamSizesRejected(t *testing.T) {
 	router := setupRouter()
 
@@ -527,6 +530,7 @@ func TestSubmitMMRCalculationMismatchedTeamSizesRejected(t *testing.T) {
 				{Id: 3, Mu: nil, Sigma: nil},
 			},
 		},
+		MMRCalculationOptions: view.MMRCalculationOptions{RequireEqualTeamSizes: true},
 	}
 
 	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
@@ -542,6 +546,658 @@ func TestSubmitMMRCalculationMismatchedTeamSizesRejected(t *testing.T) {
 	assert.NotEmpty(t, errBody.Error)
 }
 
+// submitHandicapMatch plays player 1 alone against players 2 and 3 and
+// returns the response, failing the test on a non-200 status.
+func submitHandicapMatch(t *testing.T, shortHandedScore int, fullTeamScore int) view.MMRCalculationResponse {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &shortHandedScore,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &fullTeamScore,
+			Players: []view.MMRCalculationPlayerRating{{Id: 2}, {Id: 3}},
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	return response
+}
+
+// TestSubmitMMRCalculationShortHandedLoss verifies a 1v2 loss costs the lone
+// player less than an even 1v1 loss would, since they were expected to lose.
+func TestSubmitMMRCalculationShortHandedLoss(t *testing.T) {
+	response := submitHandicapMatch(t, 5, 10)
+
+	assert.Equal(t, 1, len(response.Team1.Players))
+	assert.Equal(t, 2, len(response.Team2.Players))
+
+	shortHanded := response.Team1.Players[0]
+	assert.Less(t, shortHanded.Mu, 25.0)
+	// An even 1v1 loss lands at 23.64196380936222 (see TestSubmitMMRCalculation1v1).
+	assert.Greater(t, shortHanded.Mu, 23.64196380936222)
+
+	for _, p := range response.Team2.Players {
+		assert.Greater(t, p.Mu, 25.0)
+		assert.Less(t, p.Mu-25.0, 25.0-23.64196380936222)
+	}
+}
+
+// TestSubmitMMRCalculationShortHandedWin verifies a 1v2 win earns the lone
+// player more than an even 1v1 win would.
+func TestSubmitMMRCalculationShortHandedWin(t *testing.T) {
+	response := submitHandicapMatch(t, 10, 5)
+
+	shortHanded := response.Team1.Players[0]
+	// An even 1v1 win lands at 26.35803619063778.
+	assert.Greater(t, shortHanded.Mu, 26.35803619063778)
+
+	for _, p := range response.Team2.Players {
+		assert.Less(t, p.Mu, 25.0)
+	}
+}
+
+// TestSubmitMMRCalculationSubstituteWeight verifies a substitute who played half
+// the match counts for less in their team and moves less.
+func TestSubmitMMRCalculationSubstituteWeight(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 100
+	team2Score := 200
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &team1Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2, Weight: float64Ptr(0.5)}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4, Weight: float64Ptr(1)}},
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	// The substitute moves less than their teammate, and made their team
+	// weaker, so everyone moves less than in TestSubmitMMRCalculationNewPlayers
+	full, substitute := response.Team1.Players[0], response.Team1.Players[1]
+	assert.Less(t, 25-substitute.Mu, 25-full.Mu)
+	assert.Greater(t, substitute.Sigma, full.Sigma)
+	assert.Greater(t, full.Mu, 23.923062762073393)
+	assert.Less(t, response.Team2.Players[1].Mu, 26.076937237926607)
+}
+
+// TestSubmitMMRCalculationInvalidWeightRejected verifies weights outside (0, 1] are a 400.
+func TestSubmitMMRCalculationInvalidWeightRejected(t *testing.T) {
+	for _, weight := range []float64{0, -0.5, 1.5} {
+		router := setupRouter()
+
+		calculationController := controllers.CalculationController{}
+		router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+		team1Score := 100
+		team2Score := 200
+		requestBody := view.MMRCalculationRequest{
+			Team1: view.MMRCalculationTeam{
+				Score:   &team1Score,
+				Players: []view.MMRCalculationPlayerRating{{Id: 1, Weight: float64Ptr(weight)}},
+			},
+			Team2: view.MMRCalculationTeam{
+				Score:   &team2Score,
+				Players: []view.MMRCalculationPlayerRating{{Id: 2}},
+			},
+		}
+
+		rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "weight %v", weight)
+		assert.Contains(t, rr.Body.String(), "weight")
+	}
+}
+
+// TestSubmitMMRCalculationInvalidGlicko2FieldsRejected verifies negative
+// inactivePeriods and non-positive volatility are a 400.
+func TestSubmitMMRCalculationInvalidGlicko2FieldsRejected(t *testing.T) {
+	for field, player := range map[string]view.MMRCalculationPlayerRating{
+		"inactivePeriods": {Id: 1, InactivePeriods: intPtr(-1)},
+		"volatility":      {Id: 1, Volatility: float64Ptr(0)},
+	} {
+		router := setupRouter()
+
+		calculationController := controllers.CalculationController{}
+		router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+		team1Score := 10
+		team2Score := 5
+		requestBody := view.MMRCalculationRequest{
+			Team1:                 view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{player}},
+			Team2:                 view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+			MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "glicko2"},
+		}
+
+		rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, field)
+		assert.Contains(t, rr.Body.String(), field)
+	}
+}
+
+// submitOneVsOne posts a 1v1 between new players with the given scores and
+// outcome options.
+func submitOneVsOne(t *testing.T, team1Score int, team2Score int, outcome string, drawMargin int) *httptest.ResponseRecorder {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &team1Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 2}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{Outcome: outcome, DrawMargin: drawMargin},
+	}
+
+	return postRequest(router, "/v1/mmr-calculation", requestBody)
+}
+
+// TestSubmitMMRCalculationDraw verifies equal scores are a draw that leaves
+// equally rated players' mu unchanged and is reported as such.
+func TestSubmitMMRCalculationDraw(t *testing.T) {
+	rr := submitOneVsOne(t, 5, 5, "", 0)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, view.MMRMatchOutcome{Ranks: []int{1, 1}, Draw: true, Reason: "scores"}, response.Outcome)
+	assert.InDelta(t, 25.0, response.Team1.Players[0].Mu, 1e-9)
+	assert.InDelta(t, 25.0, response.Team2.Players[0].Mu, 1e-9)
+	assert.Less(t, response.Team1.Players[0].Sigma, 5.0)
+}
+
+// TestSubmitMMRCalculationDrawMargin verifies a 10-9 result counts as a draw
+// with a margin of 1 and is rated exactly like a tied score.
+func TestSubmitMMRCalculationDrawMargin(t *testing.T) {
+	var withMargin, tied view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(submitOneVsOne(t, 10, 9, "", 1).Body.Bytes(), &withMargin))
+	assert.NoError(t, json.Unmarshal(submitOneVsOne(t, 9, 9, "", 0).Body.Bytes(), &tied))
+
+	assert.Equal(t, view.MMRMatchOutcome{Ranks: []int{1, 1}, Draw: true, Reason: "draw-margin"}, withMargin.Outcome)
+	assert.Equal(t, 10, *withMargin.Team1.Score)
+	assert.Equal(t, tied.Team1.Players, withMargin.Team1.Players)
+	assert.Equal(t, tied.Team2.Players, withMargin.Team2.Players)
+
+	var outsideMargin view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(submitOneVsOne(t, 10, 8, "", 1).Body.Bytes(), &outsideMargin))
+	assert.Equal(t, view.MMRMatchOutcome{Ranks: []int{1, 2}, Draw: false, Reason: "scores"}, outsideMargin.Outcome)
+}
+
+// TestSubmitMMRCalculationExplicitDraw verifies outcome "draw" overrides the scores.
+func TestSubmitMMRCalculationExplicitDraw(t *testing.T) {
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(submitOneVsOne(t, 3, 10, "draw", 0).Body.Bytes(), &response))
+
+	assert.Equal(t, view.MMRMatchOutcome{Ranks: []int{1, 1}, Draw: true, Reason: "explicit"}, response.Outcome)
+	assert.InDelta(t, 25.0, response.Team1.Players[0].Mu, 1e-9)
+}
+
+func TestSubmitMMRCalculationInvalidOutcomeRejected(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, submitOneVsOne(t, 3, 10, "forfeit", 0).Code)
+	assert.Equal(t, http.StatusBadRequest, submitOneVsOne(t, 3, 10, "", -1).Code)
+}
+
+// TestSubmitMMRCalculationMarginModel verifies a blowout moves ratings further
+// than a one-goal win once a margin model is requested.
+func TestSubmitMMRCalculationMarginModel(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	submit := func(loserScore int) view.MMRCalculationResponse {
+		winnerScore := 10
+		requestBody := view.MMRCalculationRequest{
+			Team1: view.MMRCalculationTeam{
+				Score:   &winnerScore,
+				Players: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2}},
+			},
+			Team2: view.MMRCalculationTeam{
+				Score:   &loserScore,
+				Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}},
+			},
+			MMRCalculationOptions: view.MMRCalculationOptions{MarginModel: &view.MMRMarginModel{Curve: "log"}},
+		}
+
+		rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response view.MMRCalculationResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	nailBiter := submit(9)
+	blowout := submit(0)
+
+	assert.Equal(t, 1.0, nailBiter.Outcome.MarginMultiplier)
+	assert.Equal(t, 2.0, blowout.Outcome.MarginMultiplier)
+	// The nail-biter matches the plain calculation in TestSubmitMMRCalculationNewPlayers
+	assert.Equal(t, 26.076937237926607, nailBiter.Team1.Players[0].Mu)
+	assert.InDelta(t, 25+2*(26.076937237926607-25), blowout.Team1.Players[0].Mu, 1e-12)
+	assert.Less(t, blowout.Team2.Players[0].Mu, nailBiter.Team2.Players[0].Mu)
+}
+
+// TestSubmitMMRCalculationSelectsAlgorithm verifies the algorithm field picks the
+// rating model and is echoed back in the response.
+func TestSubmitMMRCalculationSelectsAlgorithm(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 100
+	team2Score := 200
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &team1Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "thurstone-mosteller-full"},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "thurstone-mosteller-full", response.Algorithm)
+	// Differs from the Plackett-Luce result asserted in TestSubmitMMRCalculationNewPlayers.
+	assert.NotEqual(t, 23.923062762073393, response.Team1.Players[0].Mu)
+	assert.Less(t, response.Team1.Players[0].Mu, 25.0)
+	assert.Greater(t, response.Team2.Players[0].Mu, 25.0)
+}
+
+// TestSubmitMMRCalculationUnknownAlgorithmRejected verifies unknown algorithm names are a 400.
+func TestSubmitMMRCalculationUnknownAlgorithmRejected(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 100
+	team2Score := 200
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &team1Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 2}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "not-an-algorithm"},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unknown algorithm")
+}
+
+// TestSubmitMMRCalculationElo verifies Elo requests return Elo ratings and
+// uncertainties in the usual mu/sigma fields.
+func TestSubmitMMRCalculationElo(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 10
+	team2Score := 9
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &team1Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2, Mu: float64Ptr(1600), Sigma: float64Ptr(50)}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "elo"},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "elo", response.Algorithm)
+	assert.Greater(t, response.Team1.Players[0].Mu, 1500.0)
+	assert.Less(t, response.Team1.Players[0].Sigma, 200.0)
+	assert.Equal(t, int(response.Team1.Players[0].Mu), response.Team1.Players[0].MMR)
+	// The established player gains less than the new teammate.
+	assert.Less(t, response.Team1.Players[1].Mu-1600, response.Team1.Players[0].Mu-1500)
+	assert.Less(t, response.Team2.Players[0].Mu, 1500.0)
+}
+
+// TestSubmitMMRCalculationGlicko2 verifies Glicko-2 requests return volatility
+// and that inactivity makes a returning player's rating move further.
+func TestSubmitMMRCalculationGlicko2(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	inactivePeriods := 12
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score: &team1Score,
+			Players: []view.MMRCalculationPlayerRating{
+				{Id: 1, Mu: float64Ptr(1600), Sigma: float64Ptr(60), Volatility: float64Ptr(0.06)},
+				{Id: 2, Mu: float64Ptr(1600), Sigma: float64Ptr(60), Volatility: float64Ptr(0.06), InactivePeriods: &inactivePeriods},
+			},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &team2Score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "glicko2"},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	active, returning := response.Team1.Players[0], response.Team1.Players[1]
+	assert.Equal(t, "glicko2", response.Algorithm)
+	assert.NotNil(t, active.Volatility)
+	assert.NotNil(t, response.Team2.Players[0].Volatility)
+	assert.Greater(t, active.Mu, 1600.0)
+	assert.Greater(t, returning.Mu, active.Mu)
+	assert.Greater(t, returning.Sigma, active.Sigma)
+	assert.Less(t, response.Team2.Players[0].Mu, 1500.0)
+	assert.Less(t, response.Team2.Players[0].Sigma, 350.0)
+}
+
+// TestSubmitMMRCalculationCarryOverPolicy verifies a hard reset starts a
+// returning player from the default rating and that the policy is echoed.
+func TestSubmitMMRCalculationCarryOverPolicy(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	isPreviousSeasonRating := true
+	score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{
+			Score:   &score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 1, Mu: float64Ptr(40), Sigma: float64Ptr(2), IsPreviousSeasonRating: &isPreviousSeasonRating}},
+		},
+		Team2: view.MMRCalculationTeam{
+			Score:   &score,
+			Players: []view.MMRCalculationPlayerRating{{Id: 2}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{CarryOver: &view.MMRCarryOverPolicy{Policy: "hard-reset"}},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, response.Team2.Players[0].Mu, response.Team1.Players[0].Mu)
+	assert.Equal(t, response.Team2.Players[0].Sigma, response.Team1.Players[0].Sigma)
+	assert.NotNil(t, response.CarryOver)
+	assert.Equal(t, "hard-reset", response.CarryOver.Policy)
+}
+
+// TestSubmitMMRCalculationCarryOverOmitted verifies the policy is only echoed
+// when a previous season rating was carried over.
+func TestSubmitMMRCalculationCarryOverOmitted(t *testing.T) {
+	rr := submitOneVsOne(t, 10, 5, "", 0)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Nil(t, response.CarryOver)
+}
+
+// TestSubmitMMRCalculationProfile verifies a rating profile's starting
+// rating and algorithm apply and that the profile is echoed.
+func TestSubmitMMRCalculationProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"casual": {"algorithm": "elo", "mu": 1200, "sigma": 100}}`), 0o600))
+	assert.NoError(t, mmr.LoadProfiles(path))
+
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1:                 view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+		Team2:                 view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+		MMRCalculationOptions: view.MMRCalculationOptions{Profile: "casual"},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "elo", response.Algorithm)
+	assert.Equal(t, "casual", response.Profile)
+	// K is halved by the lower uncertainty and doubled by the five point margin
+	assert.InDelta(t, 1220, response.Team1.Players[0].Mu, 1e-9)
+	assert.InDelta(t, 1180, response.Team2.Players[0].Mu, 1e-9)
+
+	requestBody.Profile = "unknown"
+	rr = postRequest(router, "/v1/mmr-calculation", requestBody)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// TestSubmitMMRCalculationDisplayMapping verifies players get a tier label
+// next to the unchanged raw MMR.
+func TestSubmitMMRCalculationDisplayMapping(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: 1, Mu: float64Ptr(40), Sigma: float64Ptr(2)}}},
+		Team2: view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+		MMRCalculationOptions: view.MMRCalculationOptions{
+			Display: &view.MMRDisplayMapping{
+				Mapping: "tiers",
+				Offset:  float64Ptr(1000),
+				Tiers: []view.MMRDisplayTier{
+					{Name: "Bronze", Min: 0},
+					{Name: "Silver", Min: 1500},
+					{Name: "Gold", Min: 3000},
+				},
+			},
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	winner, newcomer := response.Team1.Players[0], response.Team2.Players[0]
+	assert.Equal(t, "Gold", winner.Display.Label)
+	assert.Equal(t, "Silver", newcomer.Display.Label)
+	assert.InDelta(t, float64(newcomer.MMR)+1000, newcomer.Display.Value, 1)
+
+	requestBody.Display = &view.MMRDisplayMapping{Mapping: "tiers"}
+	rr = postRequest(router, "/v1/mmr-calculation", requestBody)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// TestSubmitMMRCalculationTierLadder verifies tiers are assigned from the MMR
+// and a previous tier is kept within the ladder's hysteresis.
+func TestSubmitMMRCalculationTierLadder(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	previousTier := "Gold"
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+		Team2: view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2, PreviousTier: &previousTier}}},
+		MMRCalculationOptions: view.MMRCalculationOptions{
+			TierLadder: &view.MMRTierLadder{
+				Tiers: []view.MMRDisplayTier{
+					{Name: "Silver", Min: 0},
+					{Name: "Gold", Min: 750},
+				},
+				Hysteresis: 100,
+			},
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	winner, loser := response.Team1.Players[0], response.Team2.Players[0]
+	assert.Greater(t, winner.MMR, 750)
+	assert.Equal(t, "Gold", winner.Tier)
+	// The loser drops below Gold but not by more than the hysteresis
+	assert.Less(t, loser.MMR, 750)
+	assert.Greater(t, loser.MMR, 650)
+	assert.Equal(t, "Gold", loser.Tier)
+}
+
+func TestSubmitMMRCalculationTierLadderUsesDisplayValue(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+		Team2: view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+		MMRCalculationOptions: view.MMRCalculationOptions{
+			Display: &view.MMRDisplayMapping{Mapping: "linear", Offset: float64Ptr(10000)},
+			TierLadder: &view.MMRTierLadder{
+				Tiers: []view.MMRDisplayTier{
+					{Name: "Silver", Min: 0},
+					{Name: "Gold", Min: 10750},
+				},
+			},
+		},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	// Thresholds apply to the display value players see, not the raw MMR
+	winner, loser := response.Team1.Players[0], response.Team2.Players[0]
+	assert.Greater(t, winner.Display.Value, 10750.0)
+	assert.Equal(t, "Gold", winner.Tier)
+	assert.Less(t, loser.Display.Value, 10750.0)
+	assert.Equal(t, "Silver", loser.Tier)
+}
+
+// TestSubmitMMRCalculationPlayerDeltas verifies results report the carried
+// over input rating, the change from it and the pre-match win probability.
+func TestSubmitMMRCalculationPlayerDeltas(t *testing.T) {
+	router := setupRouter()
+
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation", calculationController.SubmitMMRCalculation)
+
+	isPreviousSeasonRating := true
+	team1Score := 10
+	team2Score := 5
+	requestBody := view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &team1Score, Players: []view.MMRCalculationPlayerRating{{Id: 1, Mu: float64Ptr(31), Sigma: float64Ptr(1), IsPreviousSeasonRating: &isPreviousSeasonRating}}},
+		Team2: view.MMRCalculationTeam{Score: &team2Score, Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+	}
+
+	rr := postRequest(router, "/v1/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	returning, newcomer := response.Team1.Players[0], response.Team2.Players[0]
+	// A third of the previous season's lead over the default, with the default sigma
+	assert.Equal(t, 27.0, returning.Input.Mu)
+	assert.Equal(t, 5.0, returning.Input.Sigma)
+	assert.InDelta(t, returning.Mu-returning.Input.Mu, returning.Delta.Mu, 1e-9)
+	assert.Equal(t, returning.MMR-returning.Input.MMR, returning.Delta.MMR)
+	assert.Greater(t, returning.Delta.MMR, 0)
+	assert.Less(t, newcomer.Delta.MMR, 0)
+	assert.Greater(t, returning.WinProbability, 0.5)
+	assert.InDelta(t, 1, returning.WinProbability+newcomer.WinProbability, 1e-9)
+}
+
 // Helper function to create a pointer to a float64 value
 func float64Ptr(f float64) *float64 {
 	return &f
@@ -0,0 +1,186 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func setupV2Router() *gin.Engine {
+	router := setupRouter()
+	calculationController := controllers.CalculationController{}
+	router.POST("/v2/mmr-calculation", calculationController.SubmitMMRCalculationV2)
+	return router
+}
+
+// TestSubmitMMRCalculationV2FreeForAllByRank rates a four-player free-for-all
+// given in shuffled finishing order and checks results mirror the input.
+func TestSubmitMMRCalculationV2FreeForAllByRank(t *testing.T) {
+	router := setupV2Router()
+
+	requestBody := view.MMRCalculationRequestV2{
+		Teams: []view.MMRCalculationTeamV2{
+			{Rank: intPtr(3), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+			{Rank: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+			{Rank: intPtr(4), Players: []view.MMRCalculationPlayerRating{{Id: 3}}},
+			{Rank: intPtr(2), Players: []view.MMRCalculationPlayerRating{{Id: 4}}},
+		},
+	}
+
+	rr := postRequest(router, "/v2/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponseV2
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, "plackett-luce", response.Algorithm)
+	assert.Equal(t, 4, len(response.Teams))
+	for i, id := range []int64{1, 2, 3, 4} {
+		assert.Equal(t, id, response.Teams[i].Players[0].Id)
+		assert.Nil(t, response.Teams[i].Score)
+	}
+	assert.Equal(t, 3, *response.Teams[0].Rank)
+
+	first, second, third, fourth := response.Teams[1].Players[0], response.Teams[3].Players[0], response.Teams[0].Players[0], response.Teams[2].Players[0]
+	assert.Greater(t, first.Mu, second.Mu)
+	assert.Greater(t, second.Mu, third.Mu)
+	assert.Greater(t, third.Mu, fourth.Mu)
+	assert.Greater(t, first.Mu, 25.0)
+	assert.Less(t, fourth.Mu, 25.0)
+}
+
+// TestSubmitMMRCalculationV2TwoTeamsMatchesV1 verifies the two-team case of v2
+// gives the same ratings as the v1 endpoint.
+func TestSubmitMMRCalculationV2TwoTeamsMatchesV1(t *testing.T) {
+	router := setupV2Router()
+
+	requestBody := view.MMRCalculationRequestV2{
+		Teams: []view.MMRCalculationTeamV2{
+			{Score: intPtr(100), Players: []view.MMRCalculationPlayerRating{{Id: 1}, {Id: 2}}},
+			{Score: intPtr(200), Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}}},
+		},
+	}
+
+	rr := postRequest(router, "/v2/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponseV2
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	// Same values as TestSubmitMMRCalculationNewPlayers
+	assert.Equal(t, 100, *response.Teams[0].Score)
+	assert.Equal(t, 23.923062762073393, response.Teams[0].Players[0].Mu)
+	assert.Equal(t, 4.928838065802311, response.Teams[0].Players[0].Sigma)
+	assert.Equal(t, 26.076937237926607, response.Teams[1].Players[1].Mu)
+}
+
+// TestSubmitMMRCalculationV2DrawMarginGroups verifies a margin ties teams with
+// the best score of their group rather than chaining 10-9-8 into one tie.
+func TestSubmitMMRCalculationV2DrawMarginGroups(t *testing.T) {
+	router := setupV2Router()
+
+	requestBody := view.MMRCalculationRequestV2{
+		Teams: []view.MMRCalculationTeamV2{
+			{Score: intPtr(8), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+			{Score: intPtr(10), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+			{Score: intPtr(9), Players: []view.MMRCalculationPlayerRating{{Id: 3}}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{DrawMargin: 1},
+	}
+
+	rr := postRequest(router, "/v2/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRCalculationResponseV2
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, view.MMRMatchOutcome{Ranks: []int{3, 1, 1}, Draw: false, Reason: "draw-margin"}, response.Outcome)
+	assert.Equal(t, response.Teams[1].Players[0].Mu, response.Teams[2].Players[0].Mu)
+	assert.Less(t, response.Teams[0].Players[0].Mu, 25.0)
+}
+
+func TestSubmitMMRCalculationV2DrawMarginWithRanksRejected(t *testing.T) {
+	requestBody := view.MMRCalculationRequestV2{
+		Teams: []view.MMRCalculationTeamV2{
+			{Rank: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+			{Rank: intPtr(2), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+		},
+		MMRCalculationOptions: view.MMRCalculationOptions{DrawMargin: 1},
+	}
+
+	rr := postRequest(setupV2Router(), "/v2/mmr-calculation", requestBody)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSubmitMMRCalculationV2Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		request view.MMRCalculationRequestV2
+	}{
+		{
+			name: "single team",
+			request: view.MMRCalculationRequestV2{Teams: []view.MMRCalculationTeamV2{
+				{Score: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+			}},
+		},
+		{
+			name: "mixed scores and ranks",
+			request: view.MMRCalculationRequestV2{Teams: []view.MMRCalculationTeamV2{
+				{Score: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+				{Rank: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+			}},
+		},
+		{
+			name: "neither score nor rank",
+			request: view.MMRCalculationRequestV2{Teams: []view.MMRCalculationTeamV2{
+				{Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+				{Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+			}},
+		},
+		{
+			name: "duplicate player across teams",
+			request: view.MMRCalculationRequestV2{Teams: []view.MMRCalculationTeamV2{
+				{Rank: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+				{Rank: intPtr(2), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+				{Rank: intPtr(3), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+			}},
+		},
+		{
+			name: "elo with three teams",
+			request: view.MMRCalculationRequestV2{MMRCalculationOptions: view.MMRCalculationOptions{Algorithm: "elo"}, Teams: []view.MMRCalculationTeamV2{
+				{Rank: intPtr(1), Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+				{Rank: intPtr(2), Players: []view.MMRCalculationPlayerRating{{Id: 2}}},
+				{Rank: intPtr(3), Players: []view.MMRCalculationPlayerRating{{Id: 3}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postRequest(setupV2Router(), "/v2/mmr-calculation", tt.request)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var errBody struct {
+				Error string `json:"error"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errBody))
+			assert.NotEmpty(t, errBody.Error)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupPredictionRouter() *gin.Engine {
+	router := setupRouter()
+	predictionController := controllers.PredictionController{}
+	router.POST("/mmr-prediction", predictionController.SubmitMMRPrediction)
+	return router
+}
+
+func TestSubmitMMRPrediction(t *testing.T) {
+	router := setupPredictionRouter()
+
+	strongMu, strongSigma := 30.0, 3.0
+	requestBody := view.MMRPredictionRequest{
+		Team1: view.MMRPredictionTeam{Players: []view.MMRCalculationPlayerRating{
+			{Id: 1, Mu: &strongMu, Sigma: &strongSigma},
+			{Id: 2, Mu: &strongMu, Sigma: &strongSigma},
+		}},
+		Team2: view.MMRPredictionTeam{Players: []view.MMRCalculationPlayerRating{{Id: 3}, {Id: 4}}},
+	}
+
+	rr := postRequest(router, "/mmr-prediction", requestBody)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRPredictionResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Greater(t, response.Team1.WinProbability, response.Team2.WinProbability)
+	assert.InDelta(t, 1, response.Team1.WinProbability+response.Team2.WinProbability, 1e-9)
+	assert.Greater(t, response.DrawProbability, 0.0)
+	assert.Greater(t, response.MatchQuality, 0.0)
+	assert.Less(t, response.MatchQuality, 1.0)
+}
+
+func TestSubmitMMRPredictionDuplicatePlayer(t *testing.T) {
+	router := setupPredictionRouter()
+
+	requestBody := view.MMRPredictionRequest{
+		Team1: view.MMRPredictionTeam{Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+		Team2: view.MMRPredictionTeam{Players: []view.MMRCalculationPlayerRating{{Id: 1}}},
+	}
+
+	rr := postRequest(router, "/mmr-prediction", requestBody)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
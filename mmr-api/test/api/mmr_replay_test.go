@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postReplay(t *testing.T, lines ...string) []view.MMRReplayLine {
+	router := setupRouter()
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation/replay", calculationController.ReplayMMRCalculations)
+
+	var body bytes.Buffer
+	for _, line := range lines {
+		body.WriteString(line + "\n")
+	}
+	req, _ := http.NewRequest("POST", "/v1/mmr-calculation/replay", &body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	var results []view.MMRReplayLine
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var line view.MMRReplayLine
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		results = append(results, line)
+	}
+	return results
+}
+
+func replayMatch(t *testing.T, winner, loser int64) string {
+	winnerScore, loserScore := 10, 5
+	line, err := json.Marshal(view.MMRCalculationRequest{
+		Team1: view.MMRCalculationTeam{Score: &winnerScore, Players: []view.MMRCalculationPlayerRating{{Id: winner}}},
+		Team2: view.MMRCalculationTeam{Score: &loserScore, Players: []view.MMRCalculationPlayerRating{{Id: loser}}},
+	})
+	assert.NoError(t, err)
+	return string(line)
+}
+
+// TestReplayMMRCalculationsCarriesRatingsForward verifies every match is
+// streamed back in order using the ratings from earlier matches.
+func TestReplayMMRCalculationsCarriesRatingsForward(t *testing.T) {
+	results := postReplay(t, replayMatch(t, 1, 2), replayMatch(t, 1, 3), replayMatch(t, 2, 1))
+
+	assert.Equal(t, 3, len(results))
+	for i, line := range results {
+		assert.Equal(t, i, line.Index)
+		assert.Empty(t, line.Error)
+	}
+
+	first, second, third := results[0].Result, results[1].Result, results[2].Result
+	assert.Equal(t, first.Team1.Players[0].Mu, second.Team1.Players[0].Input.Mu)
+	assert.Greater(t, second.Team1.Players[0].Mu, first.Team1.Players[0].Mu)
+	assert.Equal(t, first.Team2.Players[0].Mu, third.Team1.Players[0].Input.Mu)
+	assert.Equal(t, second.Team1.Players[0].Mu, third.Team2.Players[0].Input.Mu)
+}
+
+// TestReplayMMRCalculationsStopsAtInvalidMatch verifies the stream ends with
+// an error line at the first match that can't be calculated.
+func TestReplayMMRCalculationsStopsAtInvalidMatch(t *testing.T) {
+	results := postReplay(t, replayMatch(t, 1, 2), replayMatch(t, 3, 3), replayMatch(t, 1, 2))
+
+	assert.Equal(t, 2, len(results))
+	assert.NotNil(t, results[0].Result)
+	assert.Equal(t, 1, results[1].Index)
+	assert.Nil(t, results[1].Result)
+	assert.Contains(t, results[1].Error, "duplicated")
+}
+
+func TestReplayMMRCalculationsMalformedLine(t *testing.T) {
+	results := postReplay(t, replayMatch(t, 1, 2), `{"team1": `)
+
+	assert.Equal(t, 2, len(results))
+	assert.Contains(t, results[1].Error, "invalid match")
+}
+
+func TestReplayMMRCalculationsMissingTeam(t *testing.T) {
+	results := postReplay(t, `{"team1": {"score": 1, "players": [{"id": 1}]}}`)
+
+	assert.Equal(t, 1, len(results))
+	assert.NotEmpty(t, results[0].Error)
+}
@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mmr/backend/controllers"
+	view "mmr/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupValidationRouter() *gin.Engine {
+	router := setupRouter()
+	calculationController := controllers.CalculationController{}
+	router.POST("/v1/mmr-calculation/validate", calculationController.ValidateMMRCalculations)
+	return router
+}
+
+func validate(t *testing.T, requestBody interface{}) view.MMRValidationResponse {
+	rr := postRequest(setupValidationRouter(), "/v1/mmr-calculation/validate", requestBody)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response view.MMRValidationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response
+}
+
+func problemPointers(response view.MMRValidationResponse) []string {
+	pointers := make([]string, len(response.Problems))
+	for i, problem := range response.Problems {
+		pointers[i] = problem.Pointer
+	}
+	return pointers
+}
+
+func TestValidateMMRCalculationValid(t *testing.T) {
+	response := validate(t, batchMatch(10, 5, 1, 2))
+
+	assert.True(t, response.Valid)
+	assert.NotNil(t, response.Problems)
+	assert.Empty(t, response.Problems)
+}
+
+// TestValidateMMRCalculationReportsEveryProblem verifies a single request
+// lists all its problems rather than stopping at the first.
+func TestValidateMMRCalculationReportsEveryProblem(t *testing.T) {
+	request := batchMatch(10, 40000, 1, 1)
+	request.Algorithm = "chess"
+	request.Team1.Players[0].Sigma = float64Ptr(-1)
+
+	response := validate(t, request)
+
+	assert.False(t, response.Valid)
+	assert.ElementsMatch(t, []string{
+		"/team2/score",
+		"/team2/players/0/id",
+		"/team1/players/0/sigma",
+		"/algorithm",
+	}, problemPointers(response))
+}
+
+// TestValidateMMRCalculationAcceptsNegativeScores verifies scores below zero
+// stay valid as long as they fit in an int16.
+func TestValidateMMRCalculationAcceptsNegativeScores(t *testing.T) {
+	response := validate(t, batchMatch(-3, -7, 1, 2))
+
+	assert.True(t, response.Valid)
+	assert.Empty(t, response.Problems)
+}
+
+func TestValidateMMRCalculationBatchPointers(t *testing.T) {
+	invalidOutcome := batchMatch(10, 5, 1, 2)
+	invalidOutcome.Outcome = "forfeit"
+	requestBody := view.MMRBatchRequest{
+		Matches: []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), invalidOutcome},
+		InitialRatings: []view.MMRCalculationPlayerRating{
+			{Id: 1, Mu: float64Ptr(25), Sigma: float64Ptr(8)},
+			{Id: 1, Mu: float64Ptr(25), Sigma: float64Ptr(8)},
+		},
+	}
+
+	response := validate(t, requestBody)
+
+	assert.False(t, response.Valid)
+	assert.ElementsMatch(t, []string{"/initialRatings/1/id", "/matches/1/outcome"}, problemPointers(response))
+
+	// The array form is located by index alone
+	response = validate(t, []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2), invalidOutcome})
+	assert.Equal(t, []string{"/1/outcome"}, problemPointers(response))
+}
+
+func TestValidateMMRCalculationInvalidBody(t *testing.T) {
+	rr := postRequest(setupValidationRouter(), "/v1/mmr-calculation/validate", "not a request")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestValidateMMRCalculationPlayersAndCallback(t *testing.T) {
+	request := batchMatch(10, 5, 1, 2)
+	request.Team1.Players[0].Id = 0
+	request.Team2.Players[0].Sigma = float64Ptr(0)
+
+	response := validate(t, view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{request},
+		Callback: &view.MMRCallback{Url: "https://example.com/done"},
+	})
+
+	assert.False(t, response.Valid)
+	assert.ElementsMatch(t, []string{
+		"/matches/0/team1/players/0/id",
+		"/matches/0/team2/players/0/sigma",
+		"/callback",
+	}, problemPointers(response))
+
+	// With webhooks configured only the url itself is checked
+	router := setupRouter()
+	authenticatedAs(router, callbackKey())
+	calculationController := controllers.CalculationController{Webhooks: testWebhooks()}
+	router.POST("/v1/mmr-calculation/validate", calculationController.ValidateMMRCalculations)
+	rr := postRequest(router, "/v1/mmr-calculation/validate", view.MMRBatchRequest{
+		Matches:  []view.MMRCalculationRequest{batchMatch(10, 5, 1, 2)},
+		Callback: &view.MMRCallback{Url: "ftp://example.com/done"},
+	})
+
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []string{"/callback/url"}, problemPointers(response))
+}
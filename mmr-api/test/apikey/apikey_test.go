@@ -0,0 +1,151 @@
+package apikey_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mmr/backend/apikey"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sha256Hex(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func writeKeys(t *testing.T, path string, data string) {
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := apikey.ParseKeys([]byte(`{"keys": [
+		{"name": "api", "sha256": "` + sha256Hex("api-secret") + `", "scopes": ["calculate", "predict"]},
+		{"name": "old-api", "key": "old-secret", "scopes": ["calculate"], "expiresAt": "2026-01-01T00:00:00Z"}
+	]}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(keys))
+	assert.Equal(t, "api", keys[0].Name)
+	assert.True(t, keys[0].Allows(apikey.ScopePredict))
+	assert.False(t, keys[0].Allows(apikey.ScopeAdmin))
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), keys[1].ExpiresAt)
+}
+
+func TestParseKeysInvalid(t *testing.T) {
+	tests := map[string]string{
+		"missing name":      `{"keys": [{"key": "secret", "scopes": ["admin"]}]}`,
+		"no secret":         `{"keys": [{"name": "api", "scopes": ["admin"]}]}`,
+		"both secrets":      `{"keys": [{"name": "api", "key": "secret", "sha256": "` + sha256Hex("secret") + `", "scopes": ["admin"]}]}`,
+		"short sha256":      `{"keys": [{"name": "api", "sha256": "abc", "scopes": ["admin"]}]}`,
+		"no scopes":         `{"keys": [{"name": "api", "key": "secret"}]}`,
+		"unknown scope":     `{"keys": [{"name": "api", "key": "secret", "scopes": ["delete"]}]}`,
+		"duplicated name":   `{"keys": [{"name": "api", "key": "a", "scopes": ["admin"]}, {"name": "api", "key": "b", "scopes": ["admin"]}]}`,
+		"duplicated secret": `{"keys": [{"name": "a", "key": "secret", "scopes": ["admin"]}, {"name": "b", "sha256": "` + sha256Hex("secret") + `", "scopes": ["admin"]}]}`,
+		"unknown field":     `{"keys": [{"name": "api", "key": "secret", "scopes": ["admin"], "scope": "admin"}]}`,
+		"signed only hash":  `{"keys": [{"name": "api", "sha256": "` + sha256Hex("secret") + `", "scopes": ["admin"], "signedOnly": true}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := apikey.ParseKeys([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAdminScopeAllowsEverything(t *testing.T) {
+	key := apikey.NewKey("admin", "secret", []apikey.Scope{apikey.ScopeAdmin}, time.Time{})
+
+	assert.True(t, key.Allows(apikey.ScopeCalculate))
+	assert.True(t, key.Allows(apikey.ScopePredict))
+}
+
+// TestAuthenticateDuringRotation verifies the old and new keys both work
+// until the old one expires.
+func TestAuthenticateDuringRotation(t *testing.T) {
+	keys := apikey.NewStore(
+		apikey.NewKey("new", "new-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{}),
+		apikey.NewKey("old", "old-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Now().Add(time.Hour)),
+		apikey.NewKey("retired", "retired-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Now().Add(-time.Hour)),
+	)
+
+	key, err := keys.Authenticate("new-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", key.Name)
+
+	key, err = keys.Authenticate("old-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", key.Name)
+
+	key, err = keys.Authenticate("retired-secret")
+	assert.ErrorIs(t, err, apikey.ErrExpiredKey)
+	assert.Equal(t, "retired", key.Name)
+
+	_, err = keys.Authenticate("wrong-secret")
+	assert.ErrorIs(t, err, apikey.ErrUnknownKey)
+	_, err = keys.Authenticate("")
+	assert.ErrorIs(t, err, apikey.ErrUnknownKey)
+}
+
+func TestWatchReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	writeKeys(t, path, `{"keys": [{"name": "old", "key": "old-secret", "scopes": ["admin"]}]}`)
+	keys := apikey.NewStore(apikey.NewKey("static", "static-secret", []apikey.Scope{apikey.ScopeAdmin}, time.Time{}))
+	assert.NoError(t, keys.LoadFile(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go keys.Watch(ctx, time.Millisecond)
+
+	writeKeys(t, path, `{"keys": [{"name": "new", "key": "new-secret", "scopes": ["admin"]}]}`)
+	// Make sure the change is seen even on filesystems with coarse times
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	assert.Eventually(t, func() bool {
+		_, err := keys.Authenticate("new-secret")
+		return err == nil
+	}, time.Second, time.Millisecond)
+	_, err := keys.Authenticate("old-secret")
+	assert.ErrorIs(t, err, apikey.ErrUnknownKey)
+	_, err = keys.Authenticate("static-secret")
+	assert.NoError(t, err)
+
+	// A broken file keeps the keys already loaded
+	writeKeys(t, path, `{"keys": [`)
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	time.Sleep(20 * time.Millisecond)
+	_, err = keys.Authenticate("new-secret")
+	assert.NoError(t, err)
+}
+
+func TestSignedOnlyKey(t *testing.T) {
+	keys, err := apikey.ParseKeys([]byte(`{"keys": [
+		{"name": "signer", "key": "signer-secret", "scopes": ["calculate"], "signedOnly": true},
+		{"name": "hashed", "sha256": "` + sha256Hex("hashed-secret") + `", "scopes": ["calculate"]}
+	]}`))
+	assert.NoError(t, err)
+	store := apikey.NewStore(keys...)
+
+	_, err = store.Authenticate("signer-secret")
+	assert.ErrorIs(t, err, apikey.ErrSignatureRequired)
+
+	key, err := store.Lookup("signer")
+	assert.NoError(t, err)
+	signingKey, err := key.SigningKey()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("signer-secret"), signingKey)
+
+	// Anyone who can read the keys file knows the hash, so it can't sign
+	key, err = store.Lookup("hashed")
+	assert.NoError(t, err)
+	_, err = key.SigningKey()
+	assert.ErrorIs(t, err, apikey.ErrCannotSign)
+
+	_, err = store.Lookup("missing")
+	assert.ErrorIs(t, err, apikey.ErrUnknownKey)
+}
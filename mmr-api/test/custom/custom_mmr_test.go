@@ -34,6 +34,88 @@ func TestCustomMMRCalculation(t *testing.T) {
 	assert.True(t, player2.MMR > player3.MMR && player2.MMR > player4.MMR, "team1 won")
 }
 
+func TestCustomMMRDrawBetweenEqualTeams(t *testing.T) {
+	player1 := &custom.Player{Initials: "p1", MMR: 1500, Uncertainty: 200}
+	player2 := &custom.Player{Initials: "p2", MMR: 1500, Uncertainty: 200}
+
+	custom.UpdateMMRWithMargin(&custom.Team{Players: []*custom.Player{player1}}, &custom.Team{Players: []*custom.Player{player2}}, custom.Draw, 0)
+
+	assert.Equal(t, 1500.0, player1.MMR)
+	assert.Equal(t, 1500.0, player2.MMR)
+	assert.Less(t, player1.Uncertainty, 200.0)
+}
+
+func TestCustomMMRDrawAgainstStrongerTeamGainsMMR(t *testing.T) {
+	weaker := &custom.Player{Initials: "p1", MMR: 1400, Uncertainty: 200}
+	stronger := &custom.Player{Initials: "p2", MMR: 1600, Uncertainty: 200}
+
+	custom.UpdateMMR(&custom.Team{Players: []*custom.Player{weaker}}, &custom.Team{Players: []*custom.Player{stronger}}, custom.Draw)
+
+	assert.Greater(t, weaker.MMR, 1400.0)
+	assert.Less(t, stronger.MMR, 1600.0)
+}
+
+func TestCustomMMRLargerMarginMovesFurther(t *testing.T) {
+	close1 := &custom.Player{Initials: "p1", MMR: 1500, Uncertainty: 200}
+	close2 := &custom.Player{Initials: "p2", MMR: 1500, Uncertainty: 200}
+	blowout1 := &custom.Player{Initials: "p3", MMR: 1500, Uncertainty: 200}
+	blowout2 := &custom.Player{Initials: "p4", MMR: 1500, Uncertainty: 200}
+
+	custom.UpdateMMRWithMargin(&custom.Team{Players: []*custom.Player{close1}}, &custom.Team{Players: []*custom.Player{close2}}, custom.Team1Wins, 1)
+	custom.UpdateMMRWithMargin(&custom.Team{Players: []*custom.Player{blowout1}}, &custom.Team{Players: []*custom.Player{blowout2}}, custom.Team1Wins, 10)
+
+	assert.Greater(t, blowout1.MMR, close1.MMR)
+	assert.Less(t, blowout2.MMR, close2.MMR)
+}
+
+func TestCustomMMRUncertaintyShrinksAndScalesK(t *testing.T) {
+	newPlayer := &custom.Player{Initials: "p1", MMR: 1500}
+	veteran := &custom.Player{Initials: "p2", MMR: 1500, Uncertainty: custom.MinUncertainty}
+	opponent1 := &custom.Player{Initials: "p3", MMR: 1500, Uncertainty: 200}
+	opponent2 := &custom.Player{Initials: "p4", MMR: 1500, Uncertainty: 200}
+
+	custom.UpdateMMRWithMargin(&custom.Team{Players: []*custom.Player{newPlayer}}, &custom.Team{Players: []*custom.Player{opponent1}}, custom.Team1Wins, 0)
+	custom.UpdateMMRWithMargin(&custom.Team{Players: []*custom.Player{veteran}}, &custom.Team{Players: []*custom.Player{opponent2}}, custom.Team1Wins, 0)
+
+	// A zero uncertainty counts as a new player: full K and a decayed uncertainty.
+	assert.Equal(t, 1500+custom.K*0.5, newPlayer.MMR)
+	assert.Less(t, newPlayer.Uncertainty, float64(custom.DefaultUncertainty))
+	// Established players move a quarter as much and stay at the floor.
+	assert.Equal(t, 1500+custom.K*0.5/4, veteran.MMR)
+	assert.Equal(t, float64(custom.MinUncertainty), veteran.Uncertainty)
+}
+
+func TestCustomMMRUpdateMMRUpdatesUncertainty(t *testing.T) {
+	veteran := &custom.Player{Initials: "p1", MMR: 1500, Uncertainty: custom.MinUncertainty}
+	newPlayer := &custom.Player{Initials: "p2", MMR: 1500, Uncertainty: 200}
+	opponent1 := &custom.Player{Initials: "p3", MMR: 1500}
+	opponent2 := &custom.Player{Initials: "p4", MMR: 1500}
+
+	custom.UpdateMMR(&custom.Team{Players: []*custom.Player{veteran, newPlayer}}, &custom.Team{Players: []*custom.Player{opponent1, opponent2}}, custom.Team1Wins)
+
+	// The same update as UpdateMMRWithMargin without a margin
+	assert.Equal(t, 1500+custom.K*0.5/4, veteran.MMR)
+	assert.Equal(t, 1500+custom.K*0.5, newPlayer.MMR)
+	assert.Equal(t, 1500-custom.K*0.5, opponent1.MMR)
+	assert.Less(t, newPlayer.Uncertainty, float64(custom.DefaultUncertainty))
+	assert.Less(t, opponent1.Uncertainty, float64(custom.DefaultUncertainty))
+}
+
+func TestCustomMMRUnevenTeamsUseMeanRating(t *testing.T) {
+	pair1 := &custom.Player{Initials: "p1", MMR: 1500, Uncertainty: 200}
+	pair2 := &custom.Player{Initials: "p2", MMR: 1500, Uncertainty: 200}
+	solo := &custom.Player{Initials: "p3", MMR: 1500, Uncertainty: 200}
+	pair := &custom.Team{Players: []*custom.Player{pair1, pair2}}
+
+	assert.Equal(t, 1500.0, custom.CalculateAverageTeamMMR(pair))
+
+	// Equally rated sides are even however many players they have
+	custom.UpdateMMRWithMargin(pair, &custom.Team{Players: []*custom.Player{solo}}, custom.Team2Wins, 0)
+
+	assert.Equal(t, 1500+custom.K*0.5, solo.MMR)
+	assert.Equal(t, 1500-custom.K*0.5, pair1.MMR)
+}
+
 func TestMain(m *testing.M) {
 
 	// Run tests
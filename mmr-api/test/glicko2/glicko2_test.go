@@ -0,0 +1,71 @@
+package glicko2_test
+
+import (
+	"math"
+	"mmr/backend/glicko2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGlickmanExample reproduces the worked example from Glickman's
+// "Example of the Glicko-2 system".
+func TestGlickmanExample(t *testing.T) {
+	player := glicko2.Player{Rating: 1500, RD: 200, Volatility: 0.06}
+
+	updated := player.Update([]glicko2.Result{
+		{Opponent: glicko2.Player{Rating: 1400, RD: 30}, Score: 1},
+		{Opponent: glicko2.Player{Rating: 1550, RD: 100}, Score: 0},
+		{Opponent: glicko2.Player{Rating: 1700, RD: 300}, Score: 0},
+	})
+
+	assert.InDelta(t, 1464.06, updated.Rating, 0.01)
+	assert.InDelta(t, 151.52, updated.RD, 0.01)
+	assert.InDelta(t, 0.05999, updated.Volatility, 0.00001)
+}
+
+func TestIdleGrowsRDUpToNewPlayer(t *testing.T) {
+	player := glicko2.Player{Rating: 1700, RD: 50, Volatility: 0.06}
+
+	oneMonth := player.Idle(1)
+	aLongTime := player.Idle(10000)
+
+	assert.Equal(t, 1700.0, oneMonth.Rating)
+	assert.Greater(t, oneMonth.RD, 50.0)
+	assert.Equal(t, float64(glicko2.DefaultRD), aLongTime.RD)
+	assert.Equal(t, player, player.Idle(0))
+}
+
+func TestIdleIsClosedForm(t *testing.T) {
+	player := glicko2.Player{Rating: 1700, RD: 50, Volatility: 0.06}
+
+	assert.InDelta(t, player.Idle(1).Idle(1).Idle(1).RD, player.Idle(3).RD, 1e-9)
+	assert.Equal(t, float64(glicko2.DefaultRD), player.Idle(math.MaxInt64).RD)
+	assert.Equal(t, player, player.Idle(-5))
+}
+
+func TestUpdateWithoutResultsIsIdlePeriod(t *testing.T) {
+	player := glicko2.Player{Rating: 1700, RD: 50, Volatility: 0.06}
+
+	assert.Equal(t, player.Idle(1), player.Update(nil))
+}
+
+func TestComposite(t *testing.T) {
+	composite := glicko2.Composite([]glicko2.Player{
+		{Rating: 1400, RD: 30, Volatility: 0.05},
+		{Rating: 1600, RD: 40, Volatility: 0.07},
+	})
+
+	assert.Equal(t, 1500.0, composite.Rating)
+	assert.InDelta(t, 35.355, composite.RD, 0.001)
+	assert.InDelta(t, 0.06, composite.Volatility, 1e-12)
+}
+
+func TestUpdateWithTau(t *testing.T) {
+	player := glicko2.Player{Rating: 1900, RD: 50, Volatility: 0.06}
+	upset := []glicko2.Result{{Opponent: glicko2.Player{Rating: 1300, RD: 50}, Score: 0}}
+
+	assert.Equal(t, player.Update(upset), player.UpdateWithTau(upset, glicko2.DefaultTau))
+	// A smaller tau holds volatility closer to where it was
+	assert.Less(t, player.UpdateWithTau(upset, 0.3).Volatility, player.UpdateWithTau(upset, 1.2).Volatility)
+}
@@ -0,0 +1,159 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mmr/backend/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const owner = "key:backfill"
+
+// blockingTask reports one step and waits for its context to be done.
+func blockingTask(ctx context.Context, progress func(int)) (any, error) {
+	progress(1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func waitForStatus(t *testing.T, manager *jobs.Manager, id string, status jobs.Status) jobs.Snapshot {
+	var job jobs.Snapshot
+	assert.Eventually(t, func() bool {
+		job, _ = manager.Get(id, owner)
+		return job.Status == status
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+func TestJobSucceeds(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+
+	job, _ := manager.Submit(context.Background(), owner, 2, func(ctx context.Context, progress func(int)) (any, error) {
+		progress(2)
+		return "done", nil
+	}, nil)
+	assert.Equal(t, jobs.StatusRunning, job.Status)
+
+	job = waitForStatus(t, manager, job.ID, jobs.StatusSucceeded)
+	assert.Equal(t, 2, job.Processed)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, "done", job.Result)
+	assert.False(t, job.FinishedAt.IsZero())
+}
+
+func TestJobFails(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+	taskErr := errors.New("invalid match")
+
+	job, _ := manager.Submit(context.Background(), owner, 1, func(ctx context.Context, progress func(int)) (any, error) {
+		return nil, taskErr
+	}, nil)
+
+	job = waitForStatus(t, manager, job.ID, jobs.StatusFailed)
+	assert.ErrorIs(t, job.Err, taskErr)
+	assert.Nil(t, job.Result)
+}
+
+func TestJobCancel(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+
+	job, _ := manager.Submit(context.Background(), owner, 3, blockingTask, nil)
+	_, exists := manager.Cancel(job.ID, owner)
+	assert.True(t, exists)
+
+	job = waitForStatus(t, manager, job.ID, jobs.StatusCancelled)
+	assert.ErrorIs(t, job.Err, jobs.ErrCancelled)
+
+	_, exists = manager.Cancel("unknown", owner)
+	assert.False(t, exists)
+}
+
+// TestJobOutlivesRequest verifies a job isn't cancelled with the request that
+// submitted it.
+func TestJobOutlivesRequest(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+
+	job, _ := manager.Submit(requestCtx, owner, 1, blockingTask, nil)
+	waitForStatus(t, manager, job.ID, jobs.StatusRunning)
+	cancelRequest()
+
+	time.Sleep(10 * time.Millisecond)
+	job, _ = manager.Get(job.ID, owner)
+	assert.Equal(t, jobs.StatusRunning, job.Status)
+
+	manager.Cancel(job.ID, owner)
+	manager.Wait()
+}
+
+func TestJobsStopOnShutdown(t *testing.T) {
+	serverCtx, shutdown := context.WithCancel(context.Background())
+	manager := jobs.NewManager(serverCtx)
+
+	job, _ := manager.Submit(context.Background(), owner, 3, blockingTask, nil)
+	shutdown()
+	manager.Wait()
+
+	job, _ = manager.Get(job.ID, owner)
+	assert.Equal(t, jobs.StatusCancelled, job.Status)
+	assert.ErrorIs(t, job.Err, jobs.ErrShuttingDown)
+	assert.Equal(t, 1, job.Processed)
+}
+
+func TestFinishedJobsArePruned(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+	manager.Retention = 0
+
+	first, _ := manager.Submit(context.Background(), owner, 0, func(ctx context.Context, progress func(int)) (any, error) {
+		return nil, nil
+	}, nil)
+	waitForStatus(t, manager, first.ID, jobs.StatusSucceeded)
+	time.Sleep(time.Millisecond)
+
+	second, _ := manager.Submit(context.Background(), owner, 0, blockingTask, nil)
+	_, exists := manager.Get(first.ID, owner)
+	assert.False(t, exists)
+
+	manager.Cancel(second.ID, owner)
+	manager.Wait()
+}
+
+func TestJobsHiddenFromOtherOwners(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+
+	job, _ := manager.Submit(context.Background(), owner, 1, blockingTask, nil)
+	_, exists := manager.Get(job.ID, "key:other")
+	assert.False(t, exists)
+	_, exists = manager.Cancel(job.ID, "key:other")
+	assert.False(t, exists)
+
+	job, _ = manager.Get(job.ID, owner)
+	assert.Equal(t, jobs.StatusRunning, job.Status)
+	assert.Equal(t, owner, job.Owner)
+
+	manager.Cancel(job.ID, owner)
+	manager.Wait()
+}
+
+func TestTooManyJobs(t *testing.T) {
+	manager := jobs.NewManager(context.Background())
+	manager.MaxActive = 1
+
+	first, err := manager.Submit(context.Background(), owner, 1, blockingTask, nil)
+	assert.NoError(t, err)
+	_, err = manager.Submit(context.Background(), owner, 1, blockingTask, nil)
+	assert.ErrorIs(t, err, jobs.ErrTooManyJobs)
+
+	// A finished job frees its place
+	manager.Cancel(first.ID, owner)
+	waitForStatus(t, manager, first.ID, jobs.StatusCancelled)
+	second, err := manager.Submit(context.Background(), owner, 1, blockingTask, nil)
+	assert.NoError(t, err)
+
+	manager.Cancel(second.ID, owner)
+	manager.Wait()
+}
@@ -0,0 +1,212 @@
+package jwtauth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mmr/backend/apikey"
+	"mmr/backend/jwtauth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const issuer = "https://clerk.example.com"
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJWKS(t *testing.T) string {
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "alg": "RS256", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "encryption", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func sign(t *testing.T, alg string, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := encode(header) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		assert.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + encode(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss": issuer,
+		"sub": "user_123",
+		"aud": []string{"mmr-api", "frontend"},
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+func newVerifier(t *testing.T) *jwtauth.Verifier {
+	keys := jwtauth.NewFileKeySet(writeJWKS(t))
+	assert.NoError(t, keys.Refresh(context.Background()))
+	return jwtauth.NewVerifier(keys, issuer, "mmr-api", []apikey.Scope{apikey.ScopePredict})
+}
+
+func TestVerifyRS256(t *testing.T) {
+	claims := validClaims()
+	claims["scope"] = "calculate unknown"
+
+	principal, err := newVerifier(t).Verify(context.Background(), sign(t, "RS256", "rsa", claims))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user_123", principal.Subject)
+	assert.Equal(t, []apikey.Scope{apikey.ScopePredict, apikey.ScopeCalculate}, principal.Scopes)
+	assert.False(t, principal.Allows(apikey.ScopeAdmin))
+}
+
+func TestVerifyES256WithCustomScopesClaim(t *testing.T) {
+	verifier := newVerifier(t)
+	verifier.ScopesClaim = "mmr_scopes"
+	claims := validClaims()
+	claims["aud"] = "mmr-api"
+	claims["mmr_scopes"] = []string{"admin"}
+
+	principal, err := verifier.Verify(context.Background(), sign(t, "ES256", "ec", claims))
+
+	assert.NoError(t, err)
+	assert.True(t, principal.Allows(apikey.ScopeCalculate))
+}
+
+func TestVerifyRejects(t *testing.T) {
+	tests := map[string]func() string{
+		"wrong issuer": func() string {
+			claims := validClaims()
+			claims["iss"] = "https://evil.example.com"
+			return sign(t, "RS256", "rsa", claims)
+		},
+		"wrong audience": func() string {
+			claims := validClaims()
+			claims["aud"] = "other-api"
+			return sign(t, "RS256", "rsa", claims)
+		},
+		"expired": func() string {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return sign(t, "RS256", "rsa", claims)
+		},
+		"no expiry": func() string {
+			claims := validClaims()
+			delete(claims, "exp")
+			return sign(t, "RS256", "rsa", claims)
+		},
+		"not valid yet": func() string {
+			claims := validClaims()
+			claims["nbf"] = time.Now().Add(time.Hour).Unix()
+			return sign(t, "RS256", "rsa", claims)
+		},
+		"tampered claims": func() string {
+			parts := strings.Split(sign(t, "RS256", "rsa", validClaims()), ".")
+			claims := validClaims()
+			claims["sub"] = "user_456"
+			payload, _ := json.Marshal(claims)
+			return parts[0] + "." + encode(payload) + "." + parts[2]
+		},
+		"algorithm none": func() string {
+			header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+			payload, _ := json.Marshal(validClaims())
+			return encode(header) + "." + encode(payload) + "."
+		},
+		"algorithm not the key's": func() string {
+			return sign(t, "ES256", "rsa", validClaims())
+		},
+		"unknown key": func() string {
+			return sign(t, "RS256", "other", validClaims())
+		},
+		"encryption key": func() string {
+			return sign(t, "RS256", "encryption", validClaims())
+		},
+		"malformed": func() string {
+			return "not.a-token"
+		},
+	}
+	verifier := newVerifier(t)
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), token())
+			assert.ErrorIs(t, err, jwtauth.ErrInvalidToken)
+		})
+	}
+}
+
+func TestKeySetInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`), 0o600))
+
+	assert.Error(t, jwtauth.NewFileKeySet(path).Refresh(context.Background()))
+	assert.Error(t, jwtauth.NewFileKeySet(filepath.Join(t.TempDir(), "missing.json")).Refresh(context.Background()))
+}
+
+func TestKeySetRefreshDoesNotBlockKnownKeys(t *testing.T) {
+	jwks, err := os.ReadFile(writeJWKS(t))
+	assert.NoError(t, err)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(jwks)
+	}))
+	defer server.Close()
+	keys := jwtauth.NewURLKeySet(server.URL, server.Client())
+	assert.NoError(t, keys.Refresh(context.Background()))
+	verifier := jwtauth.NewVerifier(keys, issuer, "mmr-api", nil)
+
+	var refreshed sync.WaitGroup
+	refreshed.Go(func() { assert.NoError(t, keys.Refresh(context.Background())) })
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	// Tokens signed with keys already known aren't held up by the fetch
+	_, err = verifier.Verify(context.Background(), sign(t, "RS256", "rsa", validClaims()))
+	assert.NoError(t, err)
+	// and another refresh waits for it instead of fetching again
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, keys.Refresh(ctx), context.DeadlineExceeded)
+
+	close(release)
+	refreshed.Wait()
+	assert.Equal(t, int32(2), fetches.Load())
+}
@@ -1,14 +1,32 @@
 package middleware_test
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
+	"mmr/backend/apikey"
+	"mmr/backend/jwtauth"
 	"mmr/backend/middleware"
+	"mmr/backend/signing"
 )
 
 func setupAuthRouter() *gin.Engine {
@@ -77,3 +95,203 @@ func TestRequireAdminAuth_EmptySecretNonEmptyHeader(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
+
+func setupAPIKeyRouter(scope apikey.Scope) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	keys := apikey.NewStore(
+		apikey.NewKey("calculator", "calculate-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{}),
+		apikey.NewKey("admin", "admin-secret", []apikey.Scope{apikey.ScopeAdmin}, time.Time{}),
+		apikey.NewKey("expired", "expired-secret", []apikey.Scope{apikey.ScopeAdmin}, time.Now().Add(-time.Minute)),
+	)
+	r := gin.New()
+	r.GET("/protected", middleware.RequireAPIKey(keys, scope), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(middleware.KeyNameKey))
+	})
+	return r
+}
+
+func requestWithKey(r *gin.Engine, key string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	if key != "" {
+		req.Header.Set("X-API-KEY", key)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRequireAPIKey_ScopedKey(t *testing.T) {
+	rr := requestWithKey(setupAPIKeyRouter(apikey.ScopeCalculate), "calculate-secret")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "calculator", rr.Body.String())
+}
+
+func TestRequireAPIKey_AdminKeyAllowsAnyScope(t *testing.T) {
+	rr := requestWithKey(setupAPIKeyRouter(apikey.ScopePredict), "admin-secret")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "admin", rr.Body.String())
+}
+
+func TestRequireAPIKey_MissingScope(t *testing.T) {
+	rr := requestWithKey(setupAPIKeyRouter(apikey.ScopePredict), "calculate-secret")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRequireAPIKey_ExpiredKey(t *testing.T) {
+	rr := requestWithKey(setupAPIKeyRouter(apikey.ScopeCalculate), "expired-secret")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRequireAPIKey_WrongAndEmptyKey(t *testing.T) {
+	r := setupAPIKeyRouter(apikey.ScopeCalculate)
+
+	assert.Equal(t, http.StatusUnauthorized, requestWithKey(r, "wrong-secret").Code)
+	assert.Equal(t, http.StatusUnauthorized, requestWithKey(r, "").Code)
+}
+
+// bearerToken signs an RS256 token for the issuer of setupBearerRouter.
+func bearerToken(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func setupBearerRouter(t *testing.T, key *rsa.PrivateKey, scope apikey.Scope) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, jwks, 0o600))
+	keySet := jwtauth.NewFileKeySet(path)
+	assert.NoError(t, keySet.Refresh(context.Background()))
+
+	tokens := jwtauth.NewVerifier(keySet, "https://issuer.example.com", "", []apikey.Scope{apikey.ScopePredict})
+	keys := apikey.NewStore(apikey.NewKey("calculator", "calculate-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{}))
+	r := gin.New()
+	auth := middleware.Authenticator{Keys: keys, Tokens: tokens}
+	r.GET("/protected", auth.Require(scope), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(middleware.SubjectKey)+c.GetString(middleware.KeyNameKey))
+	})
+	return r
+}
+
+func requestWithBearer(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthenticatorAcceptsAPIKeyOrBearer(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	claims := map[string]any{
+		"iss": "https://issuer.example.com",
+		"sub": "user_123",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+
+	rr := requestWithBearer(setupBearerRouter(t, key, apikey.ScopePredict), bearerToken(t, key, claims))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "user_123", rr.Body.String())
+
+	// Tokens only get the default predict scope
+	rr = requestWithBearer(setupBearerRouter(t, key, apikey.ScopeCalculate), bearerToken(t, key, claims))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rr = requestWithBearer(setupBearerRouter(t, key, apikey.ScopePredict), bearerToken(t, otherKey, claims))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// API keys still work alongside tokens
+	rr = requestWithKey(setupBearerRouter(t, key, apikey.ScopeCalculate), "calculate-secret")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "calculator", rr.Body.String())
+}
+
+func setupSignedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	signer := apikey.NewKey("signer", "signer-secret", []apikey.Scope{apikey.ScopeCalculate}, time.Time{})
+	signer.SignedOnly = true
+	hash := sha256.Sum256([]byte("hashed-secret"))
+	hashed, _ := apikey.ParseKeys([]byte(`{"keys": [{"name": "hashed", "sha256": "` + hex.EncodeToString(hash[:]) + `", "scopes": ["calculate"]}]}`))
+	keys := apikey.NewStore(append(hashed, signer, apikey.NewKey("predictor", "predict-secret", []apikey.Scope{apikey.ScopePredict}, time.Time{}))...)
+	auth := middleware.Authenticator{Keys: keys, Nonces: signing.NewNonceCache()}
+	r := gin.New()
+	r.POST("/protected", auth.Require(apikey.ScopeCalculate), func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.String(http.StatusOK, c.GetString(middleware.KeyNameKey)+" "+string(body))
+	})
+	return r
+}
+
+func signedRequest(keyName string, secret string, nonce string, signedAt time.Time, body string) *http.Request {
+	req, _ := http.NewRequest("POST", "/protected?dry=1", strings.NewReader(body))
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	req.Header.Set(signing.KeyIDHeader, keyName)
+	req.Header.Set(signing.TimestampHeader, timestamp)
+	req.Header.Set(signing.NonceHeader, nonce)
+	req.Header.Set(signing.SignatureHeader, signing.Sign([]byte(secret), timestamp, nonce, "POST", "/protected?dry=1", []byte(body)))
+	return req
+}
+
+func TestRequireSignedRequest(t *testing.T) {
+	r := setupSignedRouter()
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(signedRequest("signer", "signer-secret", "n1", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `signer {"a":1}`, rr.Body.String())
+
+	// Replayed
+	rr = serve(signedRequest("signer", "signer-secret", "n1", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = serve(signedRequest("signer", "signer-secret", "n2", time.Now().Add(-time.Hour), `{"a":1}`))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	tampered := signedRequest("signer", "signer-secret", "n3", time.Now(), `{"a":1}`)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+	assert.Equal(t, http.StatusUnauthorized, serve(tampered).Code)
+
+	rr = serve(signedRequest("signer", "wrong-secret", "n4", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Nonces are per key, so another key can use n1
+	rr = serve(signedRequest("predictor", "predict-secret", "n1", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// The hash in the keys file isn't enough to sign with
+	rr = serve(signedRequest("hashed", "hashed-secret", "n5", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	hash := sha256.Sum256([]byte("hashed-secret"))
+	rr = serve(signedRequest("hashed", string(hash[:]), "n6", time.Now(), `{"a":1}`))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	large := `"` + strings.Repeat("a", signing.MaxBodySize) + `"`
+	rr = serve(signedRequest("signer", "signer-secret", "n7", time.Now(), large))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	// Signed-only keys can't be sent as is
+	req, _ := http.NewRequest("POST", "/protected", nil)
+	req.Header.Set("X-API-KEY", "signer-secret")
+	assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+}
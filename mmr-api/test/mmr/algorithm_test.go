@@ -0,0 +1,148 @@
+package mmr__test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+	"testing"
+)
+
+func newTestTeam(algorithm mmr.RatingAlgorithm, score int16, ids ...int64) mmr.TeamV2 {
+	players := make([]mmr.PlayerV2, len(ids))
+	for i, id := range ids {
+		players[i] = mmr.PlayerV2{Id: id, Player: algorithm.NewRating()}
+	}
+	return mmr.TeamV2{Players: players, Score: score}
+}
+
+func TestAlgorithmsWinnerGainsLoserLoses(t *testing.T) {
+	for _, name := range mmr.AlgorithmNames() {
+		t.Run(name, func(t *testing.T) {
+			algorithm, err := mmr.AlgorithmByName(name)
+			assert.NoError(t, err)
+
+			rated, err := algorithm.Rate([]mmr.TeamV2{newTestTeam(algorithm, 3, 1, 2), newTestTeam(algorithm, 10, 3, 4)})
+			assert.NoError(t, err)
+			defaultRating := algorithm.NewRating()
+
+			for _, p := range rated[0].Players {
+				assert.Less(t, p.Player.Mu, defaultRating.Mu)
+				assert.Less(t, p.Player.Sigma, defaultRating.Sigma)
+			}
+			for _, p := range rated[1].Players {
+				assert.Greater(t, p.Player.Mu, defaultRating.Mu)
+				assert.Less(t, p.Player.Sigma, defaultRating.Sigma)
+			}
+		})
+	}
+}
+
+func TestAlgorithmsDoNotMutateInput(t *testing.T) {
+	for _, name := range mmr.AlgorithmNames() {
+		algorithm, _ := mmr.AlgorithmByName(name)
+		teams := []mmr.TeamV2{newTestTeam(algorithm, 0, 1), newTestTeam(algorithm, 1, 2)}
+
+		_, err := algorithm.Rate(teams)
+
+		assert.NoError(t, err)
+		assert.Equal(t, algorithm.NewRating(), teams[0].Players[0].Player, name)
+		assert.Equal(t, algorithm.NewRating(), teams[1].Players[0].Player, name)
+	}
+}
+
+func TestAlgorithmsKeepPlayerDetails(t *testing.T) {
+	for _, name := range mmr.AlgorithmNames() {
+		algorithm, _ := mmr.AlgorithmByName(name)
+		teams := []mmr.TeamV2{newTestTeam(algorithm, 0, 1), newTestTeam(algorithm, 1, 2)}
+		teams[0].Players[0].Tier = "Gold"
+		teams[0].Players[0].Weight = 0.5
+
+		rated, err := algorithm.Rate(teams)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), rated[0].Players[0].Id, name)
+		assert.Equal(t, "Gold", rated[0].Players[0].Tier, name)
+		assert.Equal(t, 0.5, rated[0].Players[0].Weight, name)
+	}
+}
+
+func TestAlgorithmPlackettLuceMatchesCalculateNewMMRV2(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.AlgorithmPlackettLuce)
+	team1 := newTestTeam(algorithm, 100, 1, 2)
+	team2 := newTestTeam(algorithm, 200, 3, 4)
+
+	rated, _ := algorithm.Rate([]mmr.TeamV2{team1, team2})
+	t1, t2 := mmr.CalculateNewMMRV2(&team1, &team2)
+
+	assert.Equal(t, t1, rated[0])
+	assert.Equal(t, t2, rated[1])
+}
+
+func TestAlgorithmByNameDefault(t *testing.T) {
+	algorithm, err := mmr.AlgorithmByName("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, mmr.DefaultAlgorithm, algorithm.Name())
+}
+
+func TestAlgorithmByNameUnknown(t *testing.T) {
+	_, err := mmr.AlgorithmByName("glicko-3")
+
+	assert.ErrorContains(t, err, "unknown algorithm")
+}
+
+func TestThurstoneMostellerDrawBetweenEqualTeamsKeepsMu(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.AlgorithmThurstoneMostellerFull)
+
+	rated, _ := algorithm.Rate([]mmr.TeamV2{newTestTeam(algorithm, 5, 1), newTestTeam(algorithm, 5, 2)})
+
+	assert.Equal(t, rated[0].Players[0].Player, rated[1].Players[0].Player)
+	assert.InDelta(t, 25.0, rated[0].Players[0].Player.Mu, 1e-3)
+	assert.Less(t, rated[0].Players[0].Player.Sigma, 5.0)
+}
+
+func TestRatePassesWeightsToEngine(t *testing.T) {
+	for _, name := range []string{mmr.AlgorithmPlackettLuce, mmr.AlgorithmBradleyTerryFull, mmr.AlgorithmThurstoneMostellerFull} {
+		algorithm, _ := mmr.AlgorithmByName(name)
+		team1 := newTestTeam(algorithm, 5, 1, 2)
+		team1.Players[1].Weight = 0.5
+		team2 := newTestTeam(algorithm, 10, 3, 4)
+
+		rated, err := mmr.Rate(algorithm, []mmr.TeamV2{team1, team2}, mmr.RateOptions{})
+		assert.NoError(t, err, name)
+		unweighted, _ := mmr.Rate(algorithm, []mmr.TeamV2{newTestTeam(algorithm, 5, 1, 2), team2}, mmr.RateOptions{})
+
+		defaultRating := algorithm.NewRating()
+		full, substitute := rated[0].Players[0].Player, rated[0].Players[1].Player
+		assert.Less(t, defaultRating.Mu-substitute.Mu, defaultRating.Mu-full.Mu, name)
+		// The substitute made their team weaker, so losing costs everyone on it
+		// less and gains the winners less
+		assert.Greater(t, full.Mu, unweighted[0].Players[0].Player.Mu, name)
+		assert.Less(t, rated[1].Players[0].Player.Mu, unweighted[1].Players[0].Player.Mu, name)
+	}
+}
+
+func TestPredictWinAccountsForWeight(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.DefaultAlgorithm)
+	team1 := newTestTeam(algorithm, 0, 1, 2)
+	team2 := newTestTeam(algorithm, 0, 3, 4)
+	assert.InDelta(t, 0.5, mmr.PredictWin([]mmr.TeamV2{team1, team2})[0], 1e-12)
+
+	team1.Players[1].Weight = 0.5
+	assert.Less(t, mmr.PredictWin([]mmr.TeamV2{team1, team2})[0], 0.5)
+}
+
+func TestRateScalesEloChangeByWeight(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.AlgorithmElo)
+	team1 := newTestTeam(algorithm, 10, 1, 2)
+	team1.Players[1].Weight = 0.5
+	team2 := newTestTeam(algorithm, 5, 3, 4)
+
+	rated, err := mmr.Rate(algorithm, []mmr.TeamV2{team1, team2}, mmr.RateOptions{})
+	assert.NoError(t, err)
+
+	// Elo doesn't take weights, so the substitute's change is scaled afterwards
+	full, substitute := rated[0].Players[0].Player, rated[0].Players[1].Player
+	defaultRating := algorithm.NewRating()
+	assert.InDelta(t, (full.Mu-defaultRating.Mu)/2, substitute.Mu-defaultRating.Mu, 1e-12)
+	assert.InDelta(t, (full.Sigma-defaultRating.Sigma)/2, substitute.Sigma-defaultRating.Sigma, 1e-12)
+}
@@ -0,0 +1,62 @@
+package mmr__test
+
+import (
+	"testing"
+
+	"github.com/intinig/go-openskill/types"
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+)
+
+func newBalancePool(mus ...float64) []mmr.PlayerV2 {
+	players := make([]mmr.PlayerV2, len(mus))
+	for i, mu := range mus {
+		players[i] = mmr.PlayerV2{Id: int64(i + 1), Player: types.Rating{Mu: mu, Sigma: 5}}
+	}
+	return players
+}
+
+func playerIds(team mmr.TeamV2) []int64 {
+	ids := make([]int64, len(team.Players))
+	for i, player := range team.Players {
+		ids[i] = player.Id
+	}
+	return ids
+}
+
+func TestBalanceTeamsPairsStrongWithWeak(t *testing.T) {
+	splits, err := mmr.BalanceTeams(newBalancePool(35, 34, 16, 15), 2, 10)
+	assert.NoError(t, err)
+
+	// Four players can only be split three ways
+	assert.Equal(t, 3, len(splits))
+	assert.Equal(t, []int64{1, 4}, playerIds(splits[0].Teams[0]))
+	assert.Equal(t, []int64{2, 3}, playerIds(splits[0].Teams[1]))
+	assert.Equal(t, []int64{1, 2}, playerIds(splits[2].Teams[0]))
+	assert.GreaterOrEqual(t, splits[0].MatchQuality, splits[1].MatchQuality)
+	assert.GreaterOrEqual(t, splits[1].MatchQuality, splits[2].MatchQuality)
+}
+
+func TestBalanceTeamsIsDeterministic(t *testing.T) {
+	pool := newBalancePool(25, 25, 25, 25, 25, 25)
+
+	first, err := mmr.BalanceTeams(pool, 3, 5)
+	assert.NoError(t, err)
+	second, err := mmr.BalanceTeams(pool, 3, 5)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	// Equal splits keep the order they were found in
+	assert.Equal(t, []int64{1, 2, 3}, playerIds(first[0].Teams[0]))
+}
+
+func TestBalanceTeamsRejectsInvalidPools(t *testing.T) {
+	_, err := mmr.BalanceTeams(newBalancePool(25, 25, 25), 2, 1)
+	assert.Error(t, err)
+
+	_, err = mmr.BalanceTeams(newBalancePool(25, 25), 0, 1)
+	assert.Error(t, err)
+
+	_, err = mmr.BalanceTeams(make([]mmr.PlayerV2, 22), 11, 1)
+	assert.Error(t, err)
+}
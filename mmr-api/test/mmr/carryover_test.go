@@ -0,0 +1,71 @@
+package mmr__test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+	view "mmr/backend/models"
+)
+
+func previousSeasonRating(mu, sigma float64) view.MMRCalculationPlayerRating {
+	isPreviousSeasonRating := true
+	return view.MMRCalculationPlayerRating{Id: 1, Mu: &mu, Sigma: &sigma, IsPreviousSeasonRating: &isPreviousSeasonRating}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestCarryOverPolicies(t *testing.T) {
+	defaultRating := mmr.NewDefaultRating()
+	previous := previousSeasonRating(37, 2)
+
+	tests := []struct {
+		name          string
+		policy        string
+		fraction      *float64
+		leagueMean    *float64
+		expectedMu    float64
+		expectedSigma float64
+	}{
+		{name: "fraction of delta by default", expectedMu: 29, expectedSigma: defaultRating.Sigma},
+		{name: "custom fraction", policy: mmr.CarryOverFractionOfDelta, fraction: float64Ptr(0.5), expectedMu: 31, expectedSigma: defaultRating.Sigma},
+		{name: "full carry inflates sigma", policy: mmr.CarryOverFull, expectedMu: 37, expectedSigma: 3},
+		{name: "hard reset", policy: mmr.CarryOverHardReset, expectedMu: defaultRating.Mu, expectedSigma: defaultRating.Sigma},
+		{name: "regress to league mean", policy: mmr.CarryOverRegressToMean, fraction: float64Ptr(0.5), leagueMean: float64Ptr(27), expectedMu: 32, expectedSigma: defaultRating.Sigma},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := mmr.NewCarryOverPolicy(tt.policy, tt.fraction, nil, tt.leagueMean)
+			assert.NoError(t, err)
+
+			newRating := policy.RatingForPlayer(previous, defaultRating)
+			assert.InDelta(t, tt.expectedMu, newRating.Mu, 1e-9)
+			assert.InDelta(t, tt.expectedSigma, newRating.Sigma, 1e-9)
+		})
+	}
+}
+
+func TestCarryOverFullCapsSigmaAtDefault(t *testing.T) {
+	policy, err := mmr.NewCarryOverPolicy(mmr.CarryOverFull, nil, float64Ptr(10), nil)
+	assert.NoError(t, err)
+
+	newRating := policy.RatingForPlayer(previousSeasonRating(30, 2), mmr.NewDefaultRating())
+	assert.Equal(t, mmr.NewDefaultRating().Sigma, newRating.Sigma)
+}
+
+func TestCarryOverPolicyValidation(t *testing.T) {
+	_, err := mmr.NewCarryOverPolicy("soft-reset", nil, nil, nil)
+	assert.Error(t, err)
+
+	_, err = mmr.NewCarryOverPolicy(mmr.CarryOverRegressToMean, nil, nil, nil)
+	assert.Error(t, err)
+
+	_, err = mmr.NewCarryOverPolicy(mmr.CarryOverFractionOfDelta, float64Ptr(1.5), nil, nil)
+	assert.Error(t, err)
+
+	_, err = mmr.NewCarryOverPolicy(mmr.CarryOverFull, nil, float64Ptr(0.5), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package mmr__test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+	view "mmr/backend/models"
+)
+
+func TestDisplayMappings(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        view.MMRDisplayMapping
+		mmr           float64
+		expectedValue float64
+		expectedLabel string
+	}{
+		{name: "linear with offset", config: view.MMRDisplayMapping{Mapping: mmr.DisplayLinear, Offset: float64Ptr(1000)}, mmr: -150, expectedValue: 850},
+		{name: "clamped below", config: view.MMRDisplayMapping{Mapping: mmr.DisplayClamped}, mmr: -150, expectedValue: 0},
+		{name: "clamped above", config: view.MMRDisplayMapping{Mapping: mmr.DisplayClamped, Max: float64Ptr(3000)}, mmr: 3500, expectedValue: 3000},
+		{name: "percentile at mean", config: view.MMRDisplayMapping{Mapping: mmr.DisplayPercentile, Mean: float64Ptr(1000), StdDev: float64Ptr(200)}, mmr: 1000, expectedValue: 50},
+		{name: "percentile one deviation up", config: view.MMRDisplayMapping{Mapping: mmr.DisplayPercentile, Mean: float64Ptr(1000), StdDev: float64Ptr(200)}, mmr: 1200, expectedValue: 84.1345},
+		{name: "tier below the first", config: tiersConfig(), mmr: -50, expectedValue: -50, expectedLabel: "Bronze"},
+		{name: "tier at threshold", config: tiersConfig(), mmr: 1000, expectedValue: 1000, expectedLabel: "Silver"},
+		{name: "top tier", config: tiersConfig(), mmr: 2500, expectedValue: 2500, expectedLabel: "Gold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := mmr.NewDisplayMapping(tt.config)
+			assert.NoError(t, err)
+
+			display := mapping.Apply(tt.mmr)
+			assert.InDelta(t, tt.expectedValue, display.Value, 1e-4)
+			assert.Equal(t, tt.expectedLabel, display.Label)
+		})
+	}
+}
+
+func tiersConfig() view.MMRDisplayMapping {
+	return view.MMRDisplayMapping{Mapping: mmr.DisplayTiers, Tiers: []view.MMRDisplayTier{
+		{Name: "Bronze", Min: 0},
+		{Name: "Silver", Min: 1000},
+		{Name: "Gold", Min: 2000},
+	}}
+}
+
+func TestDisplayMappingValidation(t *testing.T) {
+	for _, config := range []view.MMRDisplayMapping{
+		{Mapping: "logarithmic"},
+		{Mapping: mmr.DisplayLinear, Scale: float64Ptr(math.NaN())},
+		{Mapping: mmr.DisplayClamped, Min: float64Ptr(100), Max: float64Ptr(100)},
+		{Mapping: mmr.DisplayPercentile, Mean: float64Ptr(1000)},
+		{Mapping: mmr.DisplayPercentile, Mean: float64Ptr(1000), StdDev: float64Ptr(0)},
+		{Mapping: mmr.DisplayTiers},
+		{Mapping: mmr.DisplayTiers, Tiers: []view.MMRDisplayTier{{Name: "Gold", Min: 2000}, {Name: "Silver", Min: 1000}}},
+		{Mapping: mmr.DisplayTiers, Tiers: []view.MMRDisplayTier{{Min: 0}}},
+	} {
+		_, err := mmr.NewDisplayMapping(config)
+		assert.Error(t, err, config.Mapping)
+	}
+}
@@ -0,0 +1,72 @@
+package mmr__test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"mmr/backend/mmr"
+	"testing"
+)
+
+func TestMarginMultiplierCurves(t *testing.T) {
+	linear, _ := mmr.NewMarginModel(mmr.MarginCurveLinear, nil, nil)
+	log, _ := mmr.NewMarginModel("", nil, nil)
+	sqrt, _ := mmr.NewMarginModel(mmr.MarginCurveSqrt, nil, nil)
+
+	for _, model := range []mmr.MarginModel{linear, log, sqrt} {
+		assert.Equal(t, 1.0, model.Multiplier(0), model.Curve)
+		assert.Equal(t, 1.0, model.Multiplier(1), model.Curve)
+		assert.Greater(t, model.Multiplier(3), model.Multiplier(2), model.Curve)
+		assert.Equal(t, model.Multiplier(3), model.Multiplier(-3), model.Curve)
+		assert.LessOrEqual(t, model.Multiplier(1000), mmr.DefaultMarginCap, model.Curve)
+	}
+
+	assert.Equal(t, mmr.MarginCurveLog, log.Curve)
+	assert.Equal(t, 1.5, linear.Multiplier(2))
+	assert.InDelta(t, 1+0.5*math.Log(3), log.Multiplier(3), 1e-12)
+	assert.Equal(t, 1.5, sqrt.Multiplier(4))
+}
+
+func TestMarginModelCap(t *testing.T) {
+	scale, cap := 1.0, 3.0
+	model, err := mmr.NewMarginModel(mmr.MarginCurveLinear, &scale, &cap)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, model.Multiplier(2))
+	assert.Equal(t, 3.0, model.Multiplier(10))
+}
+
+func TestMarginModelInvalid(t *testing.T) {
+	negative, lowCap := -1.0, 0.5
+
+	_, err := mmr.NewMarginModel("exp", nil, nil)
+	assert.ErrorContains(t, err, "unknown margin curve")
+	_, err = mmr.NewMarginModel("", &negative, nil)
+	assert.Error(t, err)
+	_, err = mmr.NewMarginModel("", nil, &lowCap)
+	assert.Error(t, err)
+}
+
+func TestRateWithMarginScalesMuChange(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.DefaultAlgorithm)
+	scale := 1.0
+	model, _ := mmr.NewMarginModel(mmr.MarginCurveLinear, &scale, nil)
+	teams := []mmr.TeamV2{newTestTeam(algorithm, 10, 1), newTestTeam(algorithm, 8, 2)}
+
+	plain, _ := mmr.Rate(algorithm, teams, mmr.RateOptions{})
+	scaled, err := mmr.Rate(algorithm, teams, mmr.RateOptions{Margin: &model})
+
+	assert.NoError(t, err)
+	defaultRating := algorithm.NewRating()
+	assert.InDelta(t, 2*(plain[0].Players[0].Player.Mu-defaultRating.Mu), scaled[0].Players[0].Player.Mu-defaultRating.Mu, 1e-12)
+	assert.InDelta(t, 2*(plain[1].Players[0].Player.Mu-defaultRating.Mu), scaled[1].Players[0].Player.Mu-defaultRating.Mu, 1e-12)
+	assert.Equal(t, plain[0].Players[0].Player.Sigma, scaled[0].Players[0].Player.Sigma)
+}
+
+func TestRateWithMarginRejectsElo(t *testing.T) {
+	algorithm, _ := mmr.AlgorithmByName(mmr.AlgorithmElo)
+	model, _ := mmr.NewMarginModel("", nil, nil)
+
+	_, err := mmr.Rate(algorithm, []mmr.TeamV2{newTestTeam(algorithm, 10, 1), newTestTeam(algorithm, 0, 2)}, mmr.RateOptions{Margin: &model})
+
+	assert.ErrorContains(t, err, "already accounts for the score margin")
+}
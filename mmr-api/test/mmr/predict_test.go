@@ -0,0 +1,86 @@
+package mmr__test
+
+import (
+	"testing"
+
+	"github.com/intinig/go-openskill/types"
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+)
+
+func newPredictionTeam(mu float64, ids ...int64) mmr.TeamV2 {
+	players := make([]mmr.PlayerV2, len(ids))
+	for i, id := range ids {
+		players[i] = mmr.PlayerV2{Id: id, Player: types.Rating{Mu: mu, Sigma: 5}}
+	}
+	return mmr.TeamV2{Players: players}
+}
+
+func TestPredictEvenMatch(t *testing.T) {
+	teams := []mmr.TeamV2{newPredictionTeam(25, 1, 2), newPredictionTeam(25, 3, 4)}
+
+	win := mmr.PredictWin(teams)
+	assert.InDelta(t, 0.5, win[0], 1e-9)
+	assert.InDelta(t, 0.5, win[1], 1e-9)
+
+	draw := mmr.PredictDraw(teams)
+	assert.Greater(t, draw, 0.0)
+	assert.Less(t, draw, 1.0)
+}
+
+func TestPredictFavouredTeam(t *testing.T) {
+	even := []mmr.TeamV2{newPredictionTeam(25, 1, 2), newPredictionTeam(25, 3, 4)}
+	uneven := []mmr.TeamV2{newPredictionTeam(30, 1, 2), newPredictionTeam(20, 3, 4)}
+
+	win := mmr.PredictWin(uneven)
+	assert.Greater(t, win[0], 0.5)
+	assert.InDelta(t, 1, win[0]+win[1], 1e-9)
+
+	assert.Less(t, mmr.PredictDraw(uneven), mmr.PredictDraw(even))
+	assert.Less(t, mmr.MatchQuality(uneven), mmr.MatchQuality(even))
+	assert.LessOrEqual(t, mmr.MatchQuality(even), 1.0)
+}
+
+func TestPredictWinMultipleTeams(t *testing.T) {
+	teams := []mmr.TeamV2{newPredictionTeam(30, 1), newPredictionTeam(25, 2), newPredictionTeam(20, 3)}
+
+	win := mmr.PredictWin(teams)
+	assert.Greater(t, win[0], win[1])
+	assert.Greater(t, win[1], win[2])
+	assert.InDelta(t, 1, win[0]+win[1]+win[2], 1e-9)
+}
+
+func TestPredictWinForOtherScales(t *testing.T) {
+	for _, name := range []string{mmr.AlgorithmElo, mmr.AlgorithmGlicko2} {
+		algorithm, err := mmr.AlgorithmByName(name)
+		assert.NoError(t, err)
+
+		favourite := newTestTeam(algorithm, 0, 1)
+		favourite.Players[0].Player.Mu += 200
+		win := mmr.PredictWinFor(algorithm, []mmr.TeamV2{favourite, newTestTeam(algorithm, 0, 2)})
+
+		assert.Greater(t, win[0], 0.6, name)
+		assert.InDelta(t, 1, win[0]+win[1], 1e-9, name)
+	}
+}
+
+func TestPredictWinForOpenSkillScale(t *testing.T) {
+	algorithm, err := mmr.AlgorithmByName(mmr.AlgorithmPlackettLuce)
+	assert.NoError(t, err)
+	teams := []mmr.TeamV2{newPredictionTeam(30, 1), newPredictionTeam(20, 2)}
+
+	assert.Equal(t, mmr.PredictWin(teams), mmr.PredictWinFor(algorithm, teams))
+}
+
+func TestPredictWinForUsesProfileBeta(t *testing.T) {
+	profiles, err := mmr.ParseProfiles([]byte(`{"wide": {"beta": 10}}`))
+	assert.NoError(t, err)
+	algorithm, err := profiles["wide"].RatingAlgorithm(mmr.AlgorithmPlackettLuce)
+	assert.NoError(t, err)
+	teams := []mmr.TeamV2{newPredictionTeam(30, 1), newPredictionTeam(20, 2)}
+
+	// A larger beta means ratings say less about who wins
+	win := mmr.PredictWinFor(algorithm, teams)
+	assert.Greater(t, win[0], 0.5)
+	assert.Less(t, win[0], mmr.PredictWin(teams)[0])
+}
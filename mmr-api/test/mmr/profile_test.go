@@ -0,0 +1,138 @@
+package mmr__test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intinig/go-openskill/types"
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+)
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := mmr.ParseProfiles([]byte(`{
+		"office": {"mu": 30, "sigma": 6, "displayMultiplier": 100, "carryOver": {"policy": "hard-reset"}},
+		"casual": {"algorithm": "elo", "mu": 1200}
+	}`))
+	assert.NoError(t, err)
+
+	office := profiles["office"]
+	assert.Equal(t, "office", office.Name)
+	assert.Equal(t, 30.0, *office.Mu)
+	assert.Equal(t, mmr.CarryOverHardReset, office.CarryOver.Name)
+
+	algorithm, err := office.RatingAlgorithm("")
+	assert.NoError(t, err)
+	assert.Equal(t, mmr.DefaultAlgorithm, algorithm.Name())
+	assert.Equal(t, types.Rating{Mu: 30, Sigma: 6}, algorithm.NewRating())
+	assert.InDelta(t, (30.0-3*6)*100, algorithm.DisplayValue(algorithm.NewRating()), 1e-9)
+
+	casual, err := profiles["casual"].RatingAlgorithm("")
+	assert.NoError(t, err)
+	assert.Equal(t, mmr.AlgorithmElo, casual.Name())
+	assert.Equal(t, types.Rating{Mu: 1200, Sigma: 200}, casual.NewRating())
+}
+
+func TestParseProfilesRejectsInvalidSettings(t *testing.T) {
+	for _, data := range []string{
+		`{"office": {"algorithm": "trueskill"}}`,
+		`{"office": {"sigma": 0}}`,
+		`{"office": {"beta": -1}}`,
+		`{"office": {"tau": -0.1}}`,
+		`{"office": {"algorithm": "glicko2", "tau": 0}}`,
+		`{"office": {"displayMultiplier": 0}}`,
+		`{"office": {"carryOver": {"policy": "regress-to-mean"}}}`,
+		`{"office": {"sigmaa": 5}}`,
+	} {
+		_, err := mmr.ParseProfiles([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestParseProfilesKeepsZeroSettings(t *testing.T) {
+	profiles, err := mmr.ParseProfiles([]byte(`{"centred": {"mu": 0, "tau": 0}, "unset": {}}`))
+	assert.NoError(t, err)
+
+	// A zero mu is a setting of its own, not the default
+	centred, err := profiles["centred"].RatingAlgorithm("")
+	assert.NoError(t, err)
+	assert.Equal(t, types.Rating{Mu: 0, Sigma: mmr.NewDefaultRating().Sigma}, centred.NewRating())
+	assert.Equal(t, 0.0, *profiles["centred"].Tau)
+
+	unset, err := profiles["unset"].RatingAlgorithm("")
+	assert.NoError(t, err)
+	assert.Equal(t, mmr.NewDefaultRating(), unset.NewRating())
+	assert.Nil(t, profiles["unset"].Tau)
+
+	// A zero-centred elo profile starts players at 0 rather than 1500
+	elo, err := profiles["centred"].RatingAlgorithm(mmr.AlgorithmElo)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, elo.NewRating().Mu)
+	// but glicko2 can't rate with a tau of 0
+	glicko2, err := profiles["centred"].RatingAlgorithm(mmr.AlgorithmGlicko2)
+	assert.NoError(t, err)
+	_, err = glicko2.Rate([]mmr.TeamV2{newTestTeam(glicko2, 1, 1), newTestTeam(glicko2, 0, 2)})
+	assert.Error(t, err)
+}
+
+func TestProfileBetaChangesUpdates(t *testing.T) {
+	profiles, err := mmr.ParseProfiles([]byte(`{"wide": {"beta": 10}}`))
+	assert.NoError(t, err)
+
+	defaultAlgorithm, err := mmr.AlgorithmByName(mmr.AlgorithmThurstoneMostellerFull)
+	assert.NoError(t, err)
+	wideAlgorithm, err := profiles["wide"].RatingAlgorithm(mmr.AlgorithmThurstoneMostellerFull)
+	assert.NoError(t, err)
+
+	teams := []mmr.TeamV2{newTestTeam(defaultAlgorithm, 10, 1, 2), newTestTeam(defaultAlgorithm, 5, 3, 4)}
+	defaultRated, err := defaultAlgorithm.Rate(teams)
+	assert.NoError(t, err)
+	wideRated, err := wideAlgorithm.Rate(teams)
+	assert.NoError(t, err)
+
+	// A larger beta means results say less about skill
+	assert.Less(t, wideRated[0].Players[0].Player.Mu, defaultRated[0].Players[0].Player.Mu)
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"office": {"mu": 30}}`), 0o600))
+
+	assert.NoError(t, mmr.LoadProfiles(path))
+	assert.Equal(t, []string{"office"}, mmr.ProfileNames())
+
+	profile, err := mmr.ProfileByName("office")
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, *profile.Mu)
+
+	_, err = mmr.ProfileByName("league")
+	assert.Error(t, err)
+
+	profile, err = mmr.ProfileByName("")
+	assert.NoError(t, err)
+	assert.Equal(t, mmr.Profile{}, profile)
+
+	assert.Error(t, mmr.LoadProfiles(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestProfileTauChangesGlicko2Volatility(t *testing.T) {
+	profiles, err := mmr.ParseProfiles([]byte(`{"volatile": {"algorithm": "glicko2", "tau": 1.2}}`))
+	assert.NoError(t, err)
+
+	defaultAlgorithm, err := mmr.AlgorithmByName(mmr.AlgorithmGlicko2)
+	assert.NoError(t, err)
+	volatileAlgorithm, err := profiles["volatile"].RatingAlgorithm("")
+	assert.NoError(t, err)
+
+	// An upset raises the favourite's volatility, more so with a larger tau
+	favourite := mmr.TeamV2{Score: 0, Players: []mmr.PlayerV2{{Id: 1, Player: types.Rating{Mu: 1900, Sigma: 50}}}}
+	underdog := mmr.TeamV2{Score: 1, Players: []mmr.PlayerV2{{Id: 2, Player: types.Rating{Mu: 1300, Sigma: 50}}}}
+	defaultRated, err := defaultAlgorithm.Rate([]mmr.TeamV2{favourite, underdog})
+	assert.NoError(t, err)
+	volatileRated, err := volatileAlgorithm.Rate([]mmr.TeamV2{favourite, underdog})
+	assert.NoError(t, err)
+
+	assert.Greater(t, defaultRated[0].Players[0].Volatility, 0.06)
+	assert.Greater(t, volatileRated[0].Players[0].Volatility, defaultRated[0].Players[0].Volatility)
+}
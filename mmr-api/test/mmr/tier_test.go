@@ -0,0 +1,64 @@
+package mmr__test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mmr/backend/mmr"
+	view "mmr/backend/models"
+)
+
+func newTestLadder(t *testing.T, divisions int, hysteresis float64) mmr.TierLadder {
+	ladder, err := mmr.NewTierLadder(view.MMRTierLadder{
+		Tiers: []view.MMRDisplayTier{
+			{Name: "Bronze", Min: 0},
+			{Name: "Silver", Min: 1000},
+			{Name: "Gold", Min: 2200},
+			{Name: "Champion", Min: 3000},
+		},
+		Divisions:  divisions,
+		Hysteresis: hysteresis,
+	})
+	assert.NoError(t, err)
+	return ladder
+}
+
+func TestTierLadderDivisions(t *testing.T) {
+	ladder := newTestLadder(t, 3, 0)
+
+	assert.Equal(t, "Bronze III", ladder.Assign(-200, ""))
+	assert.Equal(t, "Silver III", ladder.Assign(1000, ""))
+	assert.Equal(t, "Silver II", ladder.Assign(1400, ""))
+	assert.Equal(t, "Silver I", ladder.Assign(1999, ""))
+	assert.Equal(t, "Gold II", ladder.Assign(2500, ""))
+	// The top tier has no divisions
+	assert.Equal(t, "Champion", ladder.Assign(5000, ""))
+}
+
+func TestTierLadderHysteresis(t *testing.T) {
+	ladder := newTestLadder(t, 1, 50)
+
+	// Within the hysteresis of the previous tier
+	assert.Equal(t, "Bronze", ladder.Assign(1040, "Bronze"))
+	assert.Equal(t, "Silver", ladder.Assign(960, "Silver"))
+	// Past it
+	assert.Equal(t, "Silver", ladder.Assign(1050, "Bronze"))
+	assert.Equal(t, "Bronze", ladder.Assign(949, "Silver"))
+	// Far enough to skip a tier
+	assert.Equal(t, "Gold", ladder.Assign(2300, "Bronze"))
+	// Unknown previous tiers are ignored
+	assert.Equal(t, "Silver", ladder.Assign(1040, "Platinum"))
+}
+
+func TestTierLadderValidation(t *testing.T) {
+	for _, config := range []view.MMRTierLadder{
+		{},
+		{Tiers: []view.MMRDisplayTier{{Name: "Gold", Min: 2000}, {Name: "Silver", Min: 1000}}},
+		{Tiers: []view.MMRDisplayTier{{Name: "", Min: 0}}},
+		{Tiers: []view.MMRDisplayTier{{Name: "Bronze", Min: 0}}, Divisions: 6},
+		{Tiers: []view.MMRDisplayTier{{Name: "Bronze", Min: 0}}, Hysteresis: -1},
+	} {
+		_, err := mmr.NewTierLadder(config)
+		assert.Error(t, err)
+	}
+}
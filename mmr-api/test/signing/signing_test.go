@@ -0,0 +1,67 @@
+package signing_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"mmr/backend/signing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	key := []byte("key")
+	body := []byte(`{"team1":{}}`)
+	signature := signing.Sign(key, "1760000000", "nonce", "POST", "/api/v1/mmr-calculation?x=1", body)
+
+	assert.True(t, signing.Verify(key, "1760000000", "nonce", "POST", "/api/v1/mmr-calculation?x=1", body, signature))
+	assert.False(t, signing.Verify(key, "1760000001", "nonce", "POST", "/api/v1/mmr-calculation?x=1", body, signature))
+	assert.False(t, signing.Verify(key, "1760000000", "other", "POST", "/api/v1/mmr-calculation?x=1", body, signature))
+	assert.False(t, signing.Verify(key, "1760000000", "nonce", "GET", "/api/v1/mmr-calculation?x=1", body, signature))
+	assert.False(t, signing.Verify(key, "1760000000", "nonce", "POST", "/api/v1/mmr-calculation?x=2", body, signature))
+	assert.False(t, signing.Verify(key, "1760000000", "nonce", "POST", "/api/v1/mmr-calculation?x=1", []byte(`{}`), signature))
+	assert.False(t, signing.Verify([]byte("other"), "1760000000", "nonce", "POST", "/api/v1/mmr-calculation?x=1", body, signature))
+}
+
+func TestNonceCacheRefusesReplaysAndStaleRequests(t *testing.T) {
+	nonces := signing.NewNonceCache()
+	now := time.Now()
+
+	assert.NoError(t, nonces.Use("key", "a", now, now))
+	assert.ErrorIs(t, nonces.Use("key", "a", now, now.Add(time.Minute)), signing.ErrReplayed)
+	assert.ErrorIs(t, nonces.Use("key", "b", now.Add(-signing.DefaultMaxSkew-time.Second), now), signing.ErrStale)
+	assert.ErrorIs(t, nonces.Use("key", "b", now.Add(signing.DefaultMaxSkew+time.Second), now), signing.ErrStale)
+
+	// Forgotten once too old to be accepted anyway
+	later := now.Add(signing.DefaultMaxSkew + time.Second)
+	assert.NoError(t, nonces.Use("key", "b", later, later))
+	assert.Equal(t, 1, nonces.Len())
+}
+
+func TestNonceCacheKeepsNoncesPerKey(t *testing.T) {
+	nonces := signing.NewNonceCache()
+	now := time.Now()
+
+	assert.NoError(t, nonces.Use("key", "a", now, now))
+	// Another client using the same nonce doesn't make the first's a replay
+	assert.NoError(t, nonces.Use("other", "a", now, now))
+	assert.ErrorIs(t, nonces.Use("other", "a", now, now), signing.ErrReplayed)
+}
+
+func TestNonceCacheStaysBounded(t *testing.T) {
+	nonces := signing.NewNonceCache()
+	nonces.Capacity = 3
+	now := time.Now()
+	for i := range 3 {
+		assert.NoError(t, nonces.Use("key", fmt.Sprint(i), now.Add(time.Duration(i)*time.Second), now))
+	}
+
+	assert.NoError(t, nonces.Use("key", "3", now.Add(3*time.Second), now))
+	assert.Equal(t, 3, nonces.Len())
+	// Nonce 0 was forgotten early, so requests signed when it was are refused
+	assert.ErrorIs(t, nonces.Use("key", "0", now, now), signing.ErrStale)
+	// The cache is full of newer requests
+	assert.ErrorIs(t, nonces.Use("key", "4", now.Add(time.Second), now), signing.ErrStale)
+	assert.NoError(t, nonces.Use("key", "5", now.Add(4*time.Second), now))
+}
@@ -0,0 +1,160 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mmr/backend/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var secret = []byte("webhook secret")
+
+// testClient delivers to the loopback addresses test receivers listen on.
+func testClient() *webhook.Client {
+	client := webhook.NewClient([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	client.InitialBackoff = time.Millisecond
+	return client
+}
+
+// receiver responds with the given status codes in turn, then 204.
+func receiver(t *testing.T, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, webhook.Verify(secret, r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)))
+
+		attempt := int(received.Add(1))
+		if attempt <= len(statusCodes) {
+			w.WriteHeader(statusCodes[attempt-1])
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"status":"succeeded"}`)
+	signature := webhook.Sign(secret, "1700000000", body)
+
+	assert.True(t, webhook.Verify(secret, "1700000000", body, signature))
+	assert.False(t, webhook.Verify(secret, "1700000001", body, signature))
+	assert.False(t, webhook.Verify([]byte("other secret"), "1700000000", body, signature))
+	assert.False(t, webhook.Verify(secret, "1700000000", []byte(`{"status":"failed"}`), signature))
+}
+
+func TestDeliverRetriesUntilAccepted(t *testing.T) {
+	server, received := receiver(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+
+	var attempts []webhook.Attempt
+	err := testClient().Deliver(context.Background(), secret, server.URL, map[string]string{"status": "succeeded"}, func(attempt webhook.Attempt) {
+		attempts = append(attempts, attempt)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), received.Load())
+	assert.Equal(t, 3, len(attempts))
+	assert.Equal(t, http.StatusInternalServerError, attempts[0].StatusCode)
+	assert.NotEmpty(t, attempts[0].Error)
+	assert.Equal(t, http.StatusNoContent, attempts[2].StatusCode)
+	assert.Empty(t, attempts[2].Error)
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	server, received := receiver(t, 500, 500, 500, 500, 500, 500)
+
+	err := testClient().Deliver(context.Background(), secret, server.URL, nil, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(webhook.DefaultMaxAttempts), received.Load())
+}
+
+func TestDeliverDoesNotRetryRejection(t *testing.T) {
+	server, received := receiver(t, http.StatusBadRequest)
+
+	err := testClient().Deliver(context.Background(), secret, server.URL, nil, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), received.Load())
+}
+
+func TestDeliverStopsRetryingOnShutdown(t *testing.T) {
+	server, received := receiver(t, 500, 500)
+	serverCtx, shutdown := context.WithCancel(context.Background())
+	client := testClient()
+	client.InitialBackoff = time.Hour
+	dispatcher := webhook.NewDispatcher(serverCtx, client)
+
+	dispatcher.Dispatch(context.Background(), secret, server.URL, nil, nil)
+	assert.Eventually(t, func() bool { return received.Load() == 1 }, time.Second, time.Millisecond)
+	shutdown()
+	dispatcher.Wait()
+
+	assert.Equal(t, int32(1), received.Load())
+}
+
+func TestDeliverSendsPayload(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "1", r.Header.Get(webhook.AttemptHeader))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, testClient().Deliver(context.Background(), secret, server.URL, map[string]string{"status": "succeeded"}, nil))
+	assert.Equal(t, "succeeded", payload["status"])
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+	client := webhook.NewClient(nil)
+
+	assert.NoError(t, client.ValidateURL(ctx, "https://93.184.215.14/mmr/callback"))
+	assert.Error(t, client.ValidateURL(ctx, "ftp://example.com/callback"))
+	assert.Error(t, client.ValidateURL(ctx, "/callback"))
+	for _, url := range []string{
+		"http://127.0.0.1/callback",
+		"http://localhost/callback",
+		"http://[::1]/callback",
+		"http://10.1.2.3/callback",
+		"http://192.168.0.1/callback",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/callback",
+		"http://100.64.0.1/callback",
+		"http://0.0.0.0/callback",
+		"http://[::ffff:127.0.0.1]/callback",
+	} {
+		assert.Error(t, client.ValidateURL(ctx, url), url)
+	}
+}
+
+func TestValidateURLAllowedNetworks(t *testing.T) {
+	client := webhook.NewClient([]netip.Prefix{netip.MustParsePrefix("10.0.5.0/24")})
+
+	assert.NoError(t, client.ValidateURL(context.Background(), "http://10.0.5.7/callback"))
+	assert.Error(t, client.ValidateURL(context.Background(), "http://10.0.6.7/callback"))
+}
+
+func TestDeliverRefusesPrivateAddress(t *testing.T) {
+	server, received := receiver(t)
+
+	// The address is checked when connecting too, so a URL that resolved to a
+	// public address when it was validated can't be delivered to later
+	client := webhook.NewClient(nil)
+	client.MaxAttempts = 1
+	err := client.Deliver(context.Background(), secret, server.URL, nil, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), received.Load())
+}
@@ -0,0 +1,251 @@
+// Package webhook delivers signed completion callbacks to clients.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// TimestampHeader holds the Unix time the delivery attempt was signed at.
+	TimestampHeader = "X-MMR-Timestamp"
+	// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, keyed with the webhook secret.
+	SignatureHeader = "X-MMR-Signature"
+	// AttemptHeader numbers the delivery attempt, starting at 1.
+	AttemptHeader = "X-MMR-Delivery-Attempt"
+)
+
+const (
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = time.Second
+	DefaultTimeout        = 10 * time.Second
+)
+
+// Attempt records one delivery attempt.
+type Attempt struct {
+	At         time.Time
+	StatusCode int    // Zero if no response was received
+	Error      string // Empty if the receiver accepted the callback
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which netip doesn't
+// count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Client delivers signed callbacks, retrying failed attempts with
+// exponential backoff. It only connects to public addresses, so a callback
+// URL can't reach the server's own or internal services.
+type Client struct {
+	HTTPClient     *http.Client
+	MaxAttempts    int
+	InitialBackoff time.Duration // Doubled after every failed attempt
+	// AllowedNetworks may be delivered to even though they aren't public,
+	// e.g. a receiver on the same private network
+	AllowedNetworks []netip.Prefix
+}
+
+// NewClient returns a Client with the default retries that also delivers to
+// allowedNetworks.
+func NewClient(allowedNetworks []netip.Prefix) *Client {
+	c := &Client{
+		MaxAttempts:     DefaultMaxAttempts,
+		InitialBackoff:  DefaultInitialBackoff,
+		AllowedNetworks: allowedNetworks,
+	}
+	// Checked on every connection, not just when the URL is validated, so a
+	// host that resolves differently later or a redirect can't get past it
+	dialer := &net.Dialer{Timeout: DefaultTimeout, Control: func(_, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		return c.checkAddr(addrPort.Addr())
+	}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	c.HTTPClient = &http.Client{Timeout: DefaultTimeout, Transport: transport}
+	return c
+}
+
+// ValidateURL checks that a callback URL can be delivered to: it is an
+// absolute http or https URL whose host only resolves to allowed addresses.
+func (c *Client) ValidateURL(ctx context.Context, callbackURL string) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback url: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("callback url must be an absolute http or https url")
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("callback url host can't be resolved: %w", err)
+	}
+	for _, addr := range addrs {
+		if err := c.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddr returns an error unless callbacks may be delivered to addr.
+func (c *Client) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, network := range c.AllowedNetworks {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("callback url must not resolve to a loopback, private or link-local address, got %s", addr)
+	}
+	return nil
+}
+
+// Sign returns the signature of a callback body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a callback body sent
+// at timestamp. Receivers should also reject timestamps that are too old.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Deliver POSTs payload as JSON, signed with secret, to callbackURL until the
+// receiver accepts it with a 2xx response, a 4xx other than 408 or 429
+// rejects it, or MaxAttempts is reached. record, if set, is called after
+// every attempt. An attempt already started is finished when ctx is done,
+// but no more are made.
+func (c *Client) Deliver(ctx context.Context, secret []byte, callbackURL string, payload any, record func(Attempt)) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		statusCode, err := c.send(context.WithoutCancel(ctx), secret, callbackURL, body, attempt)
+		if record != nil {
+			result := Attempt{At: time.Now(), StatusCode: statusCode}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			record(result)
+		}
+		if err == nil || !retryable(statusCode) || attempt >= c.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, context.Cause(ctx))
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, secret []byte, callbackURL string, body []byte, attempt int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("callback receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether an attempt that failed with statusCode, or
+// without a response if zero, may succeed when retried.
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode >= 500 ||
+		statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests
+}
+
+// Dispatcher delivers callbacks in the background, giving up on retries
+// once the server shuts down.
+type Dispatcher struct {
+	Client *Client
+
+	base context.Context // Done once the server shuts down
+	wg   sync.WaitGroup
+}
+
+// NewDispatcher returns a Dispatcher whose retries stop once ctx is done.
+func NewDispatcher(ctx context.Context, client *Client) *Dispatcher {
+	return &Dispatcher{Client: client, base: ctx}
+}
+
+// Deliver delivers payload to callbackURL, signed with secret, and waits for
+// it to be accepted or given up on. Retries stop once either ctx is done or
+// the server shuts down.
+func (d *Dispatcher) Deliver(ctx context.Context, secret []byte, callbackURL string, payload any, record func(Attempt)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := context.AfterFunc(d.base, func() { cancel(context.Cause(d.base)) })
+	defer stop()
+
+	err := d.Client.Deliver(ctx, secret, callbackURL, payload, record)
+	if err != nil {
+		slog.WarnContext(ctx, "webhook delivery failed",
+			"webhook.url", callbackURL,
+			"error", err,
+		)
+	}
+	return err
+}
+
+// Dispatch delivers payload to callbackURL, signed with secret, in the
+// background. The delivery keeps ctx's values but not its cancellation, so it
+// outlives the request that started it.
+func (d *Dispatcher) Dispatch(ctx context.Context, secret []byte, callbackURL string, payload any, record func(Attempt)) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		_ = d.Deliver(context.WithoutCancel(ctx), secret, callbackURL, payload, record)
+	}()
+}
+
+// Wait blocks until every background delivery has finished.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}